@@ -0,0 +1,180 @@
+package slackapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/slack-go/slack"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An AuditRecord records the execution of a command: who ran which command, where, and what the outcome was.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	TeamID    string    `json:"team,omitempty"`
+	UserID    string    `json:"user,omitempty"`
+	ChannelID string    `json:"channel,omitempty"`
+	ThreadTS  string    `json:"thread_ts,omitempty"`
+	// Command is the command's path, e.g. ["cluster", "deploy"]. For a Button, it's the button's action ID.
+	Command []string `json:"command"`
+	// Args are the command's arguments, after redaction (see WithAuditRedaction).
+	Args     []string      `json:"args,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// Failed is true if the command failed (see Fail).
+	Failed bool `json:"failed"`
+	// Error holds the error message the command replied with, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// An AuditSink receives the Bot's AuditRecord items.
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc is an adapter that allows a function to be used as an AuditSink
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// Audit calls f(ctx, record)
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// SlogAuditSink returns an AuditSink that logs each record to the logger, at Info level.
+func SlogAuditSink(logger *slog.Logger) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		logger.InfoContext(ctx, "command executed",
+			"team", record.TeamID,
+			"user", record.UserID,
+			"channel", record.ChannelID,
+			"thread_ts", record.ThreadTS,
+			"cmd", strings.Join(record.Command, " "),
+			"args", record.Args,
+			"duration", record.Duration,
+			"failed", record.Failed,
+			"error", record.Error,
+		)
+		return nil
+	})
+}
+
+// JSONAuditSink returns an AuditSink that writes each record to w as a line of JSON.
+func JSONAuditSink(w io.Writer) AuditSink {
+	var lock sync.Mutex
+	return AuditSinkFunc(func(_ context.Context, record AuditRecord) error {
+		body, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		_, err = w.Write(append(body, '\n'))
+		return err
+	})
+}
+
+// A MessagePoster posts a message to a Slack channel. slack.Client implements this interface.
+type MessagePoster interface {
+	PostMessageContext(ctx context.Context, channelID string, options ...slack.MsgOption) (string, string, error)
+}
+
+// SlackAuditSink returns an AuditSink that posts each record in the channel, using the client.
+func SlackAuditSink(client MessagePoster, channel string) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		_, _, err := client.PostMessageContext(ctx, channel, slack.MsgOptionText(formatAuditRecord(record), false))
+		return err
+	})
+}
+
+func formatAuditRecord(record AuditRecord) string {
+	user := "scheduled job"
+	if record.UserID != "" {
+		user = "<@" + record.UserID + ">"
+	}
+	outcome := ":white_check_mark:"
+	if record.Failed {
+		outcome = ":x:"
+		if record.Error != "" {
+			outcome += " " + record.Error
+		}
+	}
+	command := strings.Join(append(slices.Clone(record.Command), record.Args...), " ")
+	return fmt.Sprintf("%s ran `%s` in <#%s> (%s): %s", user, command, record.ChannelID, record.Duration.Round(time.Millisecond), outcome)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// A Redactor returns the arguments of the command, with sensitive values (e.g. passwords or tokens) redacted.
+// It must not modify args.
+type Redactor func(command []string, args []string) []string
+
+// redacted replaces redacted values.
+const redacted = "[REDACTED]"
+
+// RedactPatterns returns a Redactor that redacts all arguments matching any of the patterns. For arguments of the form
+// "name=value", the pattern is also matched against the value.
+func RedactPatterns(patterns ...*regexp.Regexp) Redactor {
+	return func(_ []string, args []string) []string {
+		redactedArgs := slices.Clone(args)
+		for i, arg := range redactedArgs {
+			name, value, ok := strings.Cut(arg, "=")
+			for _, pattern := range patterns {
+				if pattern.MatchString(arg) {
+					redactedArgs[i] = redacted
+					break
+				}
+				if ok && pattern.MatchString(value) {
+					redactedArgs[i] = name + "=" + redacted
+					break
+				}
+			}
+		}
+		return redactedArgs
+	}
+}
+
+// audited returns a function that executes f and sends an AuditRecord to the Bot's AuditSink items. If command is nil,
+// the command's path is resolved from args and its sensitive arguments are redacted. Otherwise, args must already
+// have been redacted by the caller.
+func (b *Bot) audited(command []string, args []string, f func(context.Context) []slack.MsgOption) func(context.Context) []slack.MsgOption {
+	if len(b.auditSinks) == 0 {
+		return f
+	}
+	return func(ctx context.Context) []slack.MsgOption {
+		start := time.Now()
+		output := f(ctx)
+		duration := time.Since(start)
+
+		path, params := command, args
+		if path == nil {
+			path, params = b.redact(ctx, args)
+		} else if b.redactor != nil {
+			params = b.redactor(path, params)
+		}
+		req, _ := RequestFromContext(ctx)
+		record := AuditRecord{
+			Time:      start,
+			TeamID:    req.TeamID,
+			UserID:    req.UserID,
+			ChannelID: req.ChannelID,
+			ThreadTS:  req.ThreadTS,
+			Command:   path,
+			Args:      params,
+			Duration:  duration,
+		}
+		if r, ok := ctx.Value(responsesKey{}).(*responses); ok {
+			record.Failed, record.Error = r.failure()
+		}
+		for _, sink := range b.auditSinks {
+			if err := sink.Audit(ctx, record); err != nil {
+				b.logger.Warn("failed to audit command", "cmd", strings.Join(path, " "), "err", err)
+			}
+		}
+		return output
+	}
+}
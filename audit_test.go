@@ -0,0 +1,110 @@
+package slackapp
+
+import (
+	"bytes"
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactPatterns(t *testing.T) {
+	redact := RedactPatterns(regexp.MustCompile(`^xox[a-z]-`), regexp.MustCompile(`^secret$`))
+	args := []string{"api", "xoxb-123", "--token=xoxp-456", "secret", "name=secret", "public"}
+	assert.Equal(t, []string{"api", "[REDACTED]", "--token=[REDACTED]", "[REDACTED]", "name=[REDACTED]", "public"}, redact(nil, args))
+	assert.Equal(t, "xoxb-123", args[1])
+}
+
+func TestJSONAuditSink(t *testing.T) {
+	var out bytes.Buffer
+	sink := JSONAuditSink(&out)
+	record := AuditRecord{Time: time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC), UserID: "U1", ChannelID: "C1", Command: []string{"deploy"}, Args: []string{"api"}, Duration: time.Second}
+	require.NoError(t, sink.Audit(context.Background(), record))
+	record.Failed, record.Error = true, "invalid command: foo"
+	require.NoError(t, sink.Audit(context.Background(), record))
+	assert.Equal(t, `{"time":"2024-06-01T12:00:00Z","user":"U1","channel":"C1","command":["deploy"],"args":["api"],"duration":1000000000,"failed":false}
+{"time":"2024-06-01T12:00:00Z","user":"U1","channel":"C1","command":["deploy"],"args":["api"],"duration":1000000000,"failed":true,"error":"invalid command: foo"}
+`, out.String())
+}
+
+func Test_formatAuditRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record AuditRecord
+		want   string
+	}{
+		{
+			name:   "success",
+			record: AuditRecord{UserID: "U1", ChannelID: "C1", Command: []string{"deploy"}, Args: []string{"api"}, Duration: 1234567 * time.Microsecond},
+			want:   "<@U1> ran `deploy api` in <#C1> (1.235s): :white_check_mark:",
+		},
+		{
+			name:   "failure",
+			record: AuditRecord{ChannelID: "C1", Command: []string{"report"}, Failed: true, Error: "command failed: timeout"},
+			want:   "scheduled job ran `report` in <#C1> (0s): :x: command failed: timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatAuditRecord(tt.record))
+		})
+	}
+}
+
+func TestBot_Audit(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	var records []AuditRecord
+	api := slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/"))
+	b := NewBot(api,
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithAudit(
+			AuditSinkFunc(func(_ context.Context, record AuditRecord) error {
+				records = append(records, record)
+				return nil
+			}),
+			SlackAuditSink(api, "C2"),
+		),
+		WithAuditRedaction(RedactPatterns(regexp.MustCompile(`^xox`))),
+		WithCommand("cluster", Commands{"login": HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("logged in to "+strings.Join(args[:1], ""), false)}
+		})}),
+	)
+
+	req := Request{TeamID: "T1", ChannelID: "C1", UserID: "U1", ThreadTS: "1.0"}
+	require.NoError(t, b.handle(context.Background(), req, "<@W23456789> cluster login prod xoxb-123"))
+	require.NoError(t, b.handle(context.Background(), req, "<@W23456789> foo bar"))
+
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"cluster", "login"}, records[0].Command)
+	assert.Equal(t, []string{"prod", "[REDACTED]"}, records[0].Args)
+	assert.Equal(t, "T1", records[0].TeamID)
+	assert.Equal(t, "U1", records[0].UserID)
+	assert.Equal(t, "C1", records[0].ChannelID)
+	assert.Equal(t, "1.0", records[0].ThreadTS)
+	assert.False(t, records[0].Failed)
+	assert.Empty(t, records[1].Command)
+	assert.Equal(t, []string{"foo", "bar"}, records[1].Args)
+	assert.True(t, records[1].Failed)
+	assert.Equal(t, "invalid command: supported commands: cluster", records[1].Error)
+
+	var audit []string
+	for range 4 {
+		if post := <-ts.post; post.Get("channel") == "C2" {
+			audit = append(audit, post.Get("text"))
+		}
+	}
+	require.Len(t, audit, 2)
+	assert.True(t, strings.HasPrefix(audit[0], "<@U1> ran `cluster login prod [REDACTED]` in <#C1>"), audit[0])
+	assert.Contains(t, audit[1], ":x: invalid command")
+}
@@ -0,0 +1,64 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"github.com/slack-go/slack"
+	"slices"
+)
+
+// BatchReplies determines how the Bot replies to a message with several commands. See WithBatchCommands.
+type BatchReplies int
+
+const (
+	// BatchCombined posts the output of all commands as a single message.
+	BatchCombined BatchReplies = iota + 1
+	// BatchThreaded posts the output of each command as a reply in the thread of the message with the commands.
+	BatchThreaded
+)
+
+// runBatch executes the commands in order and posts their output. A conditional command is skipped if the previous
+// command failed (or was skipped itself).
+func (b *Bot) runBatch(ctx context.Context, req Request, commands []batchCommand) error {
+	threadTS := req.ThreadTS
+	if threadTS == "" {
+		threadTS = req.TS
+	}
+	var public [][]slack.MsgOption
+	var files []File
+	var errs error
+	var failed bool
+	for _, command := range commands {
+		args := arguments(command.tokens)
+		if command.conditional && failed {
+			b.logger.Debug("skipping command", "channel", req.ChannelID, "cmd", args[0])
+			continue
+		}
+		b.record(ctx, req, args)
+		output, ok := b.execute(ctx, req, b.execution(command))
+		failed = !ok
+		for _, response := range output {
+			switch {
+			case response.Target != TargetChannel:
+				errs = errors.Join(errs, b.post(ctx, req, response))
+			case b.batch == BatchThreaded:
+				response.Options = append(slices.Clone(response.Options), slack.MsgOptionTS(threadTS))
+				errs = errors.Join(errs, b.post(ctx, req, response))
+			default:
+				if len(response.Options) > 0 {
+					public = append(public, response.Options)
+				}
+				files = append(files, response.Files...)
+			}
+		}
+	}
+	// post the combined output, and upload the commands' files with it
+	if len(public) > 0 || len(files) > 0 {
+		options, err := combineMessages(public...)
+		if err == nil {
+			err = b.post(ctx, req, Public(options...).WithFiles(files...))
+		}
+		errs = errors.Join(errs, err)
+	}
+	return errs
+}
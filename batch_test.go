@@ -0,0 +1,104 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBot_BatchCommands(t *testing.T) {
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+	})
+	fail := HandlerFunc(func(ctx context.Context, _ ...string) []slack.MsgOption {
+		return errorMessage(ctx, "failed", "command failed")
+	})
+
+	tests := []struct {
+		name    string
+		replies BatchReplies
+		input   string
+		want    []url.Values
+	}{
+		{
+			name:  "batching disabled",
+			input: "<@W23456789> echo foo; echo bar",
+			want:  []url.Values{{"text": {"foo; echo bar"}}},
+		},
+		{
+			name:    "combined",
+			replies: BatchCombined,
+			input:   "<@W23456789> echo foo; fail && echo bar\necho \"snafu;\"",
+			want: []url.Values{{
+				"text":        {"foo\nsnafu;"},
+				"attachments": {`[{"color":"bad","title":"failed","text":"command failed","blocks":null}]`},
+			}},
+		},
+		{
+			name:    "threaded",
+			replies: BatchThreaded,
+			input:   "<@W23456789>\necho foo &amp;&amp; echo bar",
+			want: []url.Values{
+				{"text": {"foo"}, "thread_ts": {"1.0"}},
+				{"text": {"bar"}, "thread_ts": {"1.0"}},
+			},
+		},
+		{
+			name:    "single command",
+			replies: BatchThreaded,
+			input:   "<@W23456789> echo foo",
+			want:    []url.Values{{"text": {"foo"}, "thread_ts": {""}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := testServer{t: t, post: make(chan url.Values, 10)}
+			s := httptest.NewServer(&ts)
+			defer s.Close()
+
+			b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+				WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+				WithBatchCommands(tt.replies),
+				WithCommand("echo", echo),
+				WithCommand("fail", fail),
+			)
+			require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}, tt.input))
+			for _, want := range tt.want {
+				post := <-ts.post
+				for k := range want {
+					assert.Equal(t, want.Get(k), post.Get(k), k)
+				}
+			}
+			assert.Empty(t, ts.post)
+		})
+	}
+}
+
+func TestBot_BatchCommands_Files(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), uploads: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithBatchCommands(BatchCombined),
+		WithCommand("export", HandlerFunc(func(ctx context.Context, args ...string) []slack.MsgOption {
+			Respond(ctx, Files(File{Name: args[0] + ".csv", Content: []byte("a,b")}))
+			return []slack.MsgOption{slack.MsgOptionText("exported "+args[0], false)}
+		})),
+	)
+	require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}, "<@W23456789> export foo; export bar"))
+
+	assert.Equal(t, "exported foo\nexported bar", (<-ts.post).Get("text"))
+	assert.Equal(t, url.Values{"channel": {"C1"}, "thread_ts": {""}, "file": {"foo.csv:a,b"}}, <-ts.uploads)
+	assert.Equal(t, url.Values{"channel": {"C1"}, "thread_ts": {""}, "file": {"bar.csv:a,b"}}, <-ts.uploads)
+	assert.Empty(t, ts.post)
+}
@@ -0,0 +1,258 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"strings"
+	"unicode/utf8"
+)
+
+// Block Kit's limits.
+const (
+	maxHeaderLength      = 150
+	maxSectionLength     = 3000
+	maxFields            = 10
+	maxFieldLength       = 2000
+	maxContextElements   = 10
+	maxActionElements    = 25
+	maxButtonTextLength  = 75
+	maxActionIDLength    = 255
+	maxButtonValueLength = 2000
+	maxAltTextLength     = 2000
+	maxImageURLLength    = 3000
+)
+
+// A MessageBuilder builds a Block Kit message. Each method adds a block to the message. Build validates the blocks
+// against Block Kit's limits and returns the message:
+//
+//	output, err := slackapp.NewMessage().
+//		Header("Deployment").
+//		Fields("*Service*", "api", "*Version*", "1.2.3").
+//		Buttons(slackapp.Button{Text: "Roll back", ActionID: "rollback", Value: "api 1.2.2", Style: slack.StyleDanger}).
+//		Build()
+//
+// Slack shows the message's text in notifications. Unless set with Text, the text is the message's header or its first section.
+type MessageBuilder struct {
+	blocks []slack.Block
+	text   string
+	errs   []error
+}
+
+// NewMessage returns a new MessageBuilder.
+func NewMessage() *MessageBuilder {
+	return &MessageBuilder{}
+}
+
+// Text sets the message's text, shown in notifications.
+func (m *MessageBuilder) Text(text string) *MessageBuilder {
+	m.text = text
+	return m
+}
+
+// Header adds a header block with the (plain) text.
+func (m *MessageBuilder) Header(text string) *MessageBuilder {
+	m.check(text != "", "header: text is empty")
+	m.check(utf8.RuneCountInString(text) <= maxHeaderLength, "header: text exceeds %d characters", maxHeaderLength)
+	return m.add(slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, text, false, false)))
+}
+
+// Section adds a section block with the (markdown) text.
+func (m *MessageBuilder) Section(text string) *MessageBuilder {
+	m.check(text != "", "section: text is empty")
+	m.check(utf8.RuneCountInString(text) <= maxSectionLength, "section: text exceeds %d characters", maxSectionLength)
+	return m.add(slack.NewSectionBlock(markdown(text), nil, nil))
+}
+
+// Fields adds a section block with the (markdown) fields. Slack shows the fields in two columns.
+func (m *MessageBuilder) Fields(fields ...string) *MessageBuilder {
+	m.check(len(fields) > 0, "fields: no fields")
+	m.check(len(fields) <= maxFields, "fields: more than %d fields", maxFields)
+	objects := make([]*slack.TextBlockObject, len(fields))
+	for i, field := range fields {
+		m.check(field != "", "fields: field %d is empty", i+1)
+		m.check(utf8.RuneCountInString(field) <= maxFieldLength, "fields: field %d exceeds %d characters", i+1, maxFieldLength)
+		objects[i] = markdown(field)
+	}
+	return m.add(slack.NewSectionBlock(nil, objects, nil))
+}
+
+// Context adds a context block with the (markdown) elements. Slack shows context in a smaller font.
+func (m *MessageBuilder) Context(elements ...string) *MessageBuilder {
+	m.check(len(elements) > 0, "context: no elements")
+	m.check(len(elements) <= maxContextElements, "context: more than %d elements", maxContextElements)
+	objects := make([]slack.MixedElement, len(elements))
+	for i, element := range elements {
+		objects[i] = markdown(element)
+	}
+	return m.add(slack.NewContextBlock("", objects...))
+}
+
+// Divider adds a divider block.
+func (m *MessageBuilder) Divider() *MessageBuilder {
+	return m.add(slack.NewDividerBlock())
+}
+
+// Image adds an image block with the image at the URL. The altText describes the image for users of screen readers.
+func (m *MessageBuilder) Image(imageURL string, altText string) *MessageBuilder {
+	m.check(imageURL != "", "image: url is empty")
+	m.check(utf8.RuneCountInString(imageURL) <= maxImageURLLength, "image: url exceeds %d characters", maxImageURLLength)
+	m.check(altText != "", "image: alt text is empty")
+	m.check(utf8.RuneCountInString(altText) <= maxAltTextLength, "image: alt text exceeds %d characters", maxAltTextLength)
+	return m.add(slack.NewImageBlock(imageURL, altText, "", nil))
+}
+
+// Buttons adds an actions block with the buttons.
+func (m *MessageBuilder) Buttons(buttons ...Button) *MessageBuilder {
+	m.check(len(buttons) > 0, "buttons: no buttons")
+	m.check(len(buttons) <= maxActionElements, "buttons: more than %d buttons", maxActionElements)
+	elements := make([]slack.BlockElement, len(buttons))
+	for i, button := range buttons {
+		for _, err := range button.validate() {
+			m.check(false, "buttons: button %d: %v", i+1, err)
+		}
+		elements[i] = button.element()
+	}
+	return m.add(slack.NewActionBlock("", elements...))
+}
+
+// Table adds the table. A table with two columns that fits in a section's fields is shown as fields, with the header
+// in bold. Otherwise, the table is shown as preformatted text.
+func (m *MessageBuilder) Table(header []string, rows [][]string) *MessageBuilder {
+	if len(header) == 2 && 2*(len(rows)+1) <= maxFields {
+		fields := []string{"*" + header[0] + "*", "*" + header[1] + "*"}
+		for _, row := range rows {
+			fields = append(fields, cellOrSpace(row, 0), cellOrSpace(row, 1))
+		}
+		return m.Fields(fields...)
+	}
+	return m.Section(codeBlock(formatTable(header, rows)))
+}
+
+// cellOrSpace returns the row's cell in the column. Fields can't be empty, so empty cells are returned as a space.
+func cellOrSpace(row []string, column int) string {
+	if column < len(row) && row[column] != "" {
+		return row[column]
+	}
+	return " "
+}
+
+// Build returns the message. It returns an error if the message violates Block Kit's limits.
+func (m *MessageBuilder) Build() ([]slack.MsgOption, error) {
+	errs := m.errs
+	if len(m.blocks) == 0 {
+		errs = append(errs, errors.New("message has no blocks"))
+	}
+	if len(m.blocks) > maxBlocks {
+		errs = append(errs, fmt.Errorf("message has more than %d blocks", maxBlocks))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return []slack.MsgOption{slack.MsgOptionText(m.notification(), false), slack.MsgOptionBlocks(m.blocks...)}, nil
+}
+
+// notification returns the message's text, shown in notifications: the text set by Text, or the message's header or
+// first section.
+func (m *MessageBuilder) notification() string {
+	if m.text != "" {
+		return m.text
+	}
+	for _, block := range m.blocks {
+		switch b := block.(type) {
+		case *slack.HeaderBlock:
+			return b.Text.Text
+		case *slack.SectionBlock:
+			if b.Text != nil {
+				return b.Text.Text
+			}
+			texts := make([]string, len(b.Fields))
+			for i, field := range b.Fields {
+				texts[i] = field.Text
+			}
+			return strings.Join(texts, " ")
+		}
+	}
+	return ""
+}
+
+func (m *MessageBuilder) add(block slack.Block) *MessageBuilder {
+	m.blocks = append(m.blocks, block)
+	return m
+}
+
+func (m *MessageBuilder) check(ok bool, format string, args ...any) {
+	if !ok {
+		m.errs = append(m.errs, fmt.Errorf("block %d: "+format, append([]any{len(m.blocks) + 1}, args...)...))
+	}
+}
+
+func markdown(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// A Button is a button in a message built by a MessageBuilder. When a user clicks the button, the Bot executes the
+// action Handler registered for the button's ActionID (see WithAction), with the button's Value as arguments.
+type Button struct {
+	Text     string
+	ActionID string
+	// Value holds the arguments passed to the action's Handler, e.g. "api 1.2.3".
+	Value string
+	// Style is the style of the button: slack.StyleDefault, slack.StylePrimary or slack.StyleDanger.
+	Style slack.Style
+}
+
+func (b Button) validate() []error {
+	var errs []error
+	if b.Text == "" {
+		errs = append(errs, errors.New("text is empty"))
+	}
+	if utf8.RuneCountInString(b.Text) > maxButtonTextLength {
+		errs = append(errs, fmt.Errorf("text exceeds %d characters", maxButtonTextLength))
+	}
+	if b.ActionID == "" {
+		errs = append(errs, errors.New("action id is empty"))
+	}
+	if len(b.ActionID) > maxActionIDLength {
+		errs = append(errs, fmt.Errorf("action id exceeds %d characters", maxActionIDLength))
+	}
+	if utf8.RuneCountInString(b.Value) > maxButtonValueLength {
+		errs = append(errs, fmt.Errorf("value exceeds %d characters", maxButtonValueLength))
+	}
+	return errs
+}
+
+func (b Button) element() slack.BlockElement {
+	button := slack.NewButtonBlockElement(b.ActionID, b.Value, slack.NewTextBlockObject(slack.PlainTextType, b.Text, false, false))
+	if b.Style != slack.StyleDefault {
+		button = button.WithStyle(b.Style)
+	}
+	return button
+}
+
+// onAction executes the action Handler for the clicked button, in the background, and posts its output in the channel
+// of the message with the button.
+func (b *Bot) onAction(ctx context.Context, callback slack.InteractionCallback, action *slack.BlockAction) {
+	handler, ok := b.actions[action.ActionID]
+	if !ok {
+		b.logger.Warn("no handler for action", "action", action.ActionID)
+		return
+	}
+	req := Request{
+		TeamID:    callback.Team.ID,
+		ChannelID: callback.Channel.ID,
+		UserID:    callback.User.ID,
+		TS:        callback.Message.Timestamp,
+		ThreadTS:  callback.Message.ThreadTimestamp,
+	}
+	args := tokenizeText(action.Value)
+	b.background(func(ctx context.Context) {
+		b.record(ctx, req, append([]string{action.ActionID}, args...))
+		if err := b.run(ctx, req, b.audited([]string{action.ActionID}, args, func(ctx context.Context) []slack.MsgOption { return handler.Handle(ctx, args...) })); err != nil {
+			b.logger.Warn("failed to post action output", "channel", req.ChannelID, "action", action.ActionID, "err", err)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/clambin/slackapp/internal/testutils"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMessageBuilder(t *testing.T) {
+	output, err := NewMessage().
+		Header("Deployment").
+		Section("deploying *api*").
+		Divider().
+		Table([]string{"service", "version"}, [][]string{{"api", "1.2.3"}, {"web"}}).
+		Context("by <@U1>").
+		Image("https://example.com/graph.png", "a graph").
+		Buttons(Button{Text: "Roll back", ActionID: "rollback", Value: "api 1.2.2", Style: slack.StyleDanger}).
+		Build()
+	require.NoError(t, err)
+
+	m, err := parseMessage(output...)
+	require.NoError(t, err)
+	assert.Equal(t, "Deployment", m.text)
+	require.Len(t, m.blocks.BlockSet, 7)
+	assert.Equal(t, "deploying *api*", m.blocks.BlockSet[1].(*slack.SectionBlock).Text.Text)
+	fields := m.blocks.BlockSet[3].(*slack.SectionBlock).Fields
+	require.Len(t, fields, 6)
+	assert.Equal(t, "*service*", fields[0].Text)
+	assert.Equal(t, "1.2.3", fields[3].Text)
+	assert.Equal(t, " ", fields[5].Text)
+	button := m.blocks.BlockSet[6].(*slack.ActionBlock).Elements.ElementSet[0].(*slack.ButtonBlockElement)
+	assert.Equal(t, "rollback", button.ActionID)
+	assert.Equal(t, "api 1.2.2", button.Value)
+	assert.Equal(t, slack.StyleDanger, button.Style)
+}
+
+func TestMessageBuilder_Text(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *MessageBuilder
+		want    string
+	}{
+		{name: "explicit", builder: NewMessage().Text("foo").Header("bar"), want: "foo"},
+		{name: "section", builder: NewMessage().Divider().Section("foo").Header("bar"), want: "foo"},
+		{name: "fields", builder: NewMessage().Fields("foo", "bar"), want: "foo bar"},
+		{name: "none", builder: NewMessage().Divider(), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tt.builder.Build()
+			require.NoError(t, err)
+			m, err := parseMessage(output...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.text)
+		})
+	}
+}
+
+func TestMessageBuilder_Table(t *testing.T) {
+	output, err := NewMessage().Table([]string{"service", "version", "env"}, [][]string{{"api", "1.2.3", "prod"}}).Build()
+	require.NoError(t, err)
+	m, err := parseMessage(output...)
+	require.NoError(t, err)
+	assert.Equal(t, "```\nservice  version  env\napi      1.2.3    prod\n```", m.blocks.BlockSet[0].(*slack.SectionBlock).Text.Text)
+}
+
+func TestMessageBuilder_Limits(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *MessageBuilder
+		wantErr string
+	}{
+		{name: "no blocks", builder: NewMessage(), wantErr: "message has no blocks"},
+		{name: "header", builder: NewMessage().Header(strings.Repeat("x", 151)), wantErr: "block 1: header: text exceeds 150 characters"},
+		{name: "section", builder: NewMessage().Divider().Section(""), wantErr: "block 2: section: text is empty"},
+		{name: "fields", builder: NewMessage().Fields(make([]string, 11)...), wantErr: "block 1: fields: more than 10 fields"},
+		{name: "image", builder: NewMessage().Image("https://example.com/graph.png", ""), wantErr: "block 1: image: alt text is empty"},
+		{name: "button", builder: NewMessage().Buttons(Button{Text: strings.Repeat("x", 76)}), wantErr: "block 1: buttons: button 1: text exceeds 75 characters\nblock 1: buttons: button 1: action id is empty"},
+		{name: "blocks", builder: func() *MessageBuilder {
+			m := NewMessage()
+			for range 51 {
+				m.Divider()
+			}
+			return m
+		}(), wantErr: "message has more than 50 blocks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestBot_Action(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := newBotWith(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")), &testutils.FakeHandler{},
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithAction("rollback", HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("rolling back "+strings.Join(args, " to "), false)}
+		})),
+	)
+
+	callback := slack.InteractionCallback{
+		Type:    slack.InteractionTypeBlockActions,
+		User:    slack.User{ID: "U1"},
+		Channel: slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: "unknown", Value: "foo"},
+			{ActionID: "rollback", Value: "api 1.2.2"},
+		}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() { errCh <- b.Run(ctx) }()
+
+	// the interaction is acknowledged right away and the action is executed in the Bot's Run loop
+	assert.Nil(t, b.onInteraction(ctx, callback))
+	post := <-ts.post
+	assert.Equal(t, "C1", post.Get("channel"))
+	assert.Equal(t, "rolling back api to 1.2.2", post.Get("text"))
+	assert.Empty(t, ts.post)
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
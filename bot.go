@@ -8,8 +8,11 @@ import (
 	"github.com/slack-go/slack/socketmode"
 	"io"
 	"log/slog"
-	"regexp"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Bot is a SlackApp application that receives commands by mentioning the bot in a channel. The bot executes the commands
@@ -17,19 +20,55 @@ import (
 type Bot struct {
 	*SlackApp
 	Commands
-	logger *slog.Logger
+	matching      Matching
+	fallback      Resolver
+	batch         BatchReplies
+	filters       map[string]Filter
+	listeners     []Listener
+	actions       map[string]Handler
+	limiter       *rateLimiter
+	auditSinks    []AuditSink
+	redactor      Redactor
+	userClient    *slack.Client
+	sessions      sessions
+	replies       replies
+	deleteReplies bool
+	catalog       Catalog
+	languages     Store
+	locales       map[string]string
+	oversize      OversizePolicy
+	logger        *slog.Logger
+	home          HomeRenderer
+	history       history
+	store         Store
+	scheduler     scheduler
+	clients       ClientProvider
+	botUsers      map[string]string
+	lock          sync.Mutex
+	work          chan func(context.Context)
+	stopped       chan struct{}
 }
 
 // NewBot creates a Bot for the Slack client.
 func NewBot(client *slack.Client, options ...BotOptionFunc) *Bot {
 	b := makeBot(options...)
 	b.SlackApp = NewSlackApp(client, b.logger.With("component", "slackapp"))
+	b.SlackApp.TeamEvents = make(chan Event)
+	b.registerCallbacks()
+	if b.clients != nil {
+		b.SlackApp.Clients = b.clients
+	}
 	return b
 }
 
 func newBotWith(c *slack.Client, h socketModeHandler, options ...BotOptionFunc) *Bot {
 	b := makeBot(options...)
 	b.SlackApp = newSlackAppWithSocketModeHandler(socketmode.New(c), h, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.SlackApp.TeamEvents = make(chan Event)
+	b.registerCallbacks()
+	if b.clients != nil {
+		b.SlackApp.Clients = b.clients
+	}
 	return b
 }
 
@@ -37,25 +76,48 @@ func makeBot(options ...BotOptionFunc) *Bot {
 	b := Bot{
 		Commands: make(Commands),
 		logger:   slog.Default(),
+		history:  history{size: defaultHistorySize},
+		replies:  replies{size: defaultRepliesSize},
+		sessions: sessions{timeout: defaultSessionTimeout},
+		store:    &MemoryStore{},
+		botUsers: make(map[string]string),
+		locales:  make(map[string]string),
+		work:     make(chan func(context.Context)),
+		stopped:  make(chan struct{}),
 	}
 	for _, o := range options {
 		o(&b)
 	}
+	b.scheduler.store = Namespace(b.store, "bot", "schedules")
+	b.languages = Namespace(b.store, "bot", "languages")
 	return &b
 }
 
 // Run starts the bot. It connects to Slack and waits for a command. It executes the command and posts the output in the channel
 // where the command was issued.
 func (b *Bot) Run(ctx context.Context) error {
-	botUserID, err := b.userID()
-	if err != nil {
-		return err
+	// with a single workspace, verify the bot token before connecting
+	var err error
+	if _, ok := b.SlackApp.Clients.(staticClientProvider); ok {
+		if _, err = b.botUserID(ctx, ""); err != nil {
+			return err
+		}
 	}
 
 	b.logger.Debug("starting Bot")
 	defer b.logger.Debug("shutting down Bot")
+	defer close(b.stopped)
 	errCh := make(chan error)
 	go func() { errCh <- b.SlackApp.Run(ctx) }()
+	if err = b.scheduler.load(ctx); err != nil {
+		b.logger.Warn("failed to load scheduled jobs", "err", err)
+	}
+	go b.scheduler.run(ctx, func(_ context.Context, job Job) {
+		b.background(func(ctx context.Context) { b.runJob(ctx, job) })
+	})
+	b.sessions.lock.Lock()
+	b.sessions.expire = b.expireSession(ctx)
+	b.sessions.lock.Unlock()
 
 	for {
 		select {
@@ -66,14 +128,24 @@ func (b *Bot) Run(ctx context.Context) error {
 				err = fmt.Errorf("slackapp failed: %w", err)
 			}
 			return err
-		case ev := <-b.SlackApp.Events:
+		case f := <-b.work:
+			f(ctx)
+		case ev := <-b.SlackApp.TeamEvents:
 			switch data := ev.Data.(type) {
 			case *slackevents.AppMentionEvent:
-				_ = b.handle(ctx, data.Channel, data.Text)
+				req := Request{TeamID: ev.TeamID, ChannelID: data.Channel, UserID: data.User, TS: data.TimeStamp, ThreadTS: data.ThreadTimeStamp}
+				if !b.converse(ctx, req, data.Text) {
+					_ = b.handle(ctx, req, data.Text)
+				}
 			case *slackevents.MessageEvent:
-				// don't process our own messages
-				if data.User != botUserID {
-					_ = b.handle(ctx, data.Channel, data.Text)
+				b.onMessage(ctx, ev.TeamID, data)
+			case *slackevents.ReactionAddedEvent:
+				b.onReaction(ctx, ev.TeamID, data)
+			case *slackevents.AppHomeOpenedEvent:
+				if data.Tab == "home" {
+					if err = b.publishHome(ctx, ev.TeamID, data.User); err != nil {
+						b.logger.Warn("failed to publish app home", "user", data.User, "err", err)
+					}
 				}
 			default:
 				b.logger.Warn("received unexpected Event API event", "type", ev.Type)
@@ -82,19 +154,129 @@ func (b *Bot) Run(ctx context.Context) error {
 	}
 }
 
-func (b *Bot) handle(ctx context.Context, channel string, input string) error {
-	args := tokenizeText(removeUserID(input))
-	b.logger.Debug("executing command", "channel", channel, "cmd", args[0])
-	resp := b.Handle(ctx, args...)
-	_, _, err := b.SlackApp.Client.PostMessage(channel, resp...)
-	return err
+// registerCallbacks handles interactions and slash commands as they arrive, so they're acknowledged in time (and
+// forms are opened before their trigger expires), even if the Bot is executing a command.
+func (b *Bot) registerCallbacks() {
+	b.SlackApp.InteractionHandler = func(cb slack.InteractionCallback) any {
+		return b.onInteraction(context.Background(), cb)
+	}
+	b.SlackApp.SlashCommandHandler = func(cmd slack.SlashCommand) any {
+		return b.onSlashCommand(context.Background(), cmd)
+	}
 }
 
-func (b *Bot) userID() (string, error) {
-	auth, err := b.SlackApp.AuthTest()
+// background executes f in the Bot's Run loop. Slack expects interactions and slash commands to be acknowledged within
+// 3 seconds, so the Bot acknowledges them right away and executes their commands in the background. Like the commands
+// received as events, these commands are executed one at a time, so Handlers don't need to be safe for concurrent use.
+func (b *Bot) background(f func(context.Context)) {
+	go func() {
+		select {
+		case b.work <- f:
+		case <-b.stopped:
+		}
+	}()
+}
+
+// handle executes the commands in the input and posts their output. The Bot remembers its replies, so it can update
+// them if the user edits the message with the commands.
+func (b *Bot) handle(ctx context.Context, req Request, input string) error {
+	return b.tracked(ctx, req, func(ctx context.Context) error {
+		return b.handleCommands(ctx, req, input)
+	})
+}
+
+func (b *Bot) handleCommands(ctx context.Context, req Request, input string) error {
+	botUserID, err := b.botUserID(ctx, req.TeamID)
+	if err != nil {
+		b.logger.Warn("failed to determine bot user", "team", req.TeamID, "err", err)
+	}
+	var commands []batchCommand
+	for _, command := range tokenizeCommands(input, b.batch != 0, b.filters != nil) {
+		if command.tokens = stripMention(command.tokens, botUserID); len(command.tokens) > 0 {
+			command.tokens = b.resolveFallback(ctx, req, command.tokens)
+			commands = append(commands, command)
+		}
+	}
+
+	if req.TS != "" && b.deletesMessage(ctx, commands) {
+		// don't reveal the sensitive command's output, or the output of the other commands in the message
+		replyTrackerFrom(ctx).makePrivate()
+		b.replies.deleting(req)
+		if err = b.deleteMessage(ctx, req); err != nil {
+			b.replies.deletedByBot(req)
+			b.logger.Warn("failed to delete message with sensitive command", "channel", req.ChannelID, "err", err)
+		}
+	}
+
+	switch len(commands) {
+	case 0:
+		return b.run(ctx, req, b.help)
+	case 1:
+		b.record(ctx, req, arguments(commands[0].tokens))
+		return b.run(ctx, req, b.execution(commands[0]))
+	default:
+		return b.runBatch(ctx, req, commands)
+	}
+}
+
+// execution returns a function that executes the command and pipes its output through the command's filters.
+func (b *Bot) execution(command batchCommand) func(context.Context) []slack.MsgOption {
+	args := arguments(command.tokens)
+	var filters [][]string
+	for _, tokens := range command.filters {
+		if len(tokens) > 0 {
+			filters = append(filters, arguments(tokens))
+		}
+	}
+	var handler Handler = b.Commands
+	if b.limiter != nil {
+		handler = b.limiter
+	}
+	return b.audited(nil, args, func(ctx context.Context) []slack.MsgOption {
+		output := handler.Handle(ctx, args...)
+		if len(filters) > 0 && len(output) > 0 {
+			output = b.filter(ctx, output, filters)
+		}
+		return output
+	})
+}
+
+// record logs the command and adds it to the user's history, with its sensitive arguments redacted.
+func (b *Bot) record(ctx context.Context, req Request, args []string) {
+	b.logger.Debug("executing command", "channel", req.ChannelID, "cmd", args[0])
+	path, params := b.redact(ctx, args)
+	b.history.add(req.UserID, HistoryEntry{Timestamp: time.Now(), Channel: req.ChannelID, Command: strings.Join(append(path, params...), " ")})
+}
+
+// help returns the reply to a message that mentions the bot without a command.
+func (b *Bot) help(ctx context.Context) []slack.MsgOption {
+	return []slack.MsgOption{slack.MsgOptionAttachments(slack.Attachment{
+		Title: Translate(ctx, "supported commands"),
+		Text:  markdownList(b.GetCommands()),
+	})}
+}
+
+// client returns the Slack client for the workspace.
+func (b *Bot) client(ctx context.Context, teamID string) (*slack.Client, error) {
+	return b.SlackApp.ClientFor(ctx, teamID)
+}
+
+// botUserID returns the bot's user ID in the workspace.
+func (b *Bot) botUserID(ctx context.Context, teamID string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if userID, ok := b.botUsers[teamID]; ok {
+		return userID, nil
+	}
+	client, err := b.client(ctx, teamID)
+	if err != nil {
+		return "", err
+	}
+	auth, err := client.AuthTestContext(ctx)
 	if err != nil {
 		return "", fmt.Errorf("auth: %w", err)
 	}
+	b.botUsers[teamID] = auth.UserID
 	return auth.UserID, nil
 }
 
@@ -117,29 +299,230 @@ func WithCommand(verb string, handler Handler) BotOptionFunc {
 	}
 }
 
-////////////////////////////////////////////////////////////////////////////////////////////////////////////f////
+// WithAlias registers one or more aliases for the command's verb. See Commands.Alias.
+func WithAlias(verb string, aliases ...string) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.Commands.Alias(verb, aliases...)
+	}
+}
 
-var tokenizerRegExp = regexp.MustCompile(`[^\s"]+|"([^"]*)"`)
+// WithMatching sets how the Bot matches the verbs of a command, in addition to an exact match. E.g.
+// WithMatching(MatchCaseInsensitive|MatchPrefix) lets users type "Dep" for "deploy". The default is an exact match.
+func WithMatching(matching Matching) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.matching = matching
+	}
+}
 
-func tokenizeText(input string) []string {
-	cleanInput := input
-	for _, quote := range []string{"“", "”", "'"} {
-		cleanInput = strings.ReplaceAll(cleanInput, quote, "\"")
+// WithFallback sets the Resolver that maps a message to a command when it doesn't start with a known command,
+// e.g. Intents. If the Resolver can't map the message either, the Bot replies that the command is invalid.
+func WithFallback(resolver Resolver) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.fallback = resolver
 	}
-	output := tokenizerRegExp.FindAllString(cleanInput, -1)
+}
 
-	for index, word := range output {
-		output[index] = strings.Trim(word, "\"")
+// WithBatchCommands lets users send several commands in one message: one per line, or separated by ";" or "&&".
+// The Bot executes the commands in order. A command following "&&" is only executed if the previous command succeeded
+// (see Fail). The replies determine whether the output of all commands is posted as a single message, or as a reply
+// per command in the message's thread.
+func WithBatchCommands(replies BatchReplies) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.batch = replies
 	}
-	return output
 }
 
-var userIDRegExp = regexp.MustCompile(`<@\w+> (.*)$`)
+// WithPipelines lets users pipe the output of a command through the built-in filters (see Filters), e.g.
+// "logs api | grep error | tail 20".
+func WithPipelines() BotOptionFunc {
+	return func(bot *Bot) {
+		if bot.filters == nil {
+			bot.filters = make(map[string]Filter)
+		}
+		maps.Copy(bot.filters, Filters())
+	}
+}
+
+// WithFilter registers a filter that users can pipe the output of a command through. This enables pipelines, but doesn't
+// register the built-in filters: use WithPipelines for that.
+func WithFilter(name string, filter Filter) BotOptionFunc {
+	return func(bot *Bot) {
+		if bot.filters == nil {
+			bot.filters = make(map[string]Filter)
+		}
+		bot.filters[name] = filter
+	}
+}
 
-func removeUserID(input string) string {
-	matches := userIDRegExp.FindStringSubmatch(input)
-	if len(matches) != 2 {
-		return input
+// WithListener registers a Listener that reacts to channel messages matching its pattern. This requires the bot to
+// subscribe to the message events of the channels (e.g. message.channels). Once a Listener is registered, the Bot only
+// executes commands in channels if the bot is mentioned.
+func WithListener(listener Listener) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.listeners = append(bot.listeners, listener)
+	}
+}
+
+// WithAction registers the Handler executed when a user clicks a Button with the actionID. The Handler receives the
+// Button's Value as arguments and its output is posted in the channel of the message with the Button.
+func WithAction(actionID string, handler Handler) BotOptionFunc {
+	return func(bot *Bot) {
+		if bot.actions == nil {
+			bot.actions = make(map[string]Handler)
+		}
+		bot.actions[actionID] = handler
+	}
+}
+
+// WithRateLimit limits how often users can execute each command. To limit only some commands, or to set different limits
+// per command, register the commands with a RateLimited Handler instead.
+func WithRateLimit(limit RateLimit) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.limiter = newRateLimiter(bot.Commands, limit)
+	}
+}
+
+// WithAudit sends an AuditRecord to each sink whenever the Bot executes a command, a Button's action, a Form or a
+// scheduled job. See SlogAuditSink, JSONAuditSink and SlackAuditSink for the built-in sinks.
+func WithAudit(sinks ...AuditSink) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.auditSinks = append(bot.auditSinks, sinks...)
+	}
+}
+
+// WithAuditRedaction sets the Redactor that removes sensitive values from the arguments recorded in an AuditRecord,
+// e.g. RedactPatterns(regexp.MustCompile(`^xox[a-z]-`)).
+func WithAuditRedaction(redactor Redactor) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.redactor = redactor
+	}
+}
+
+// WithUserToken sets the user token the Bot uses to delete messages containing sensitive commands (see Sensitive).
+// Deleting other users' messages requires the token of a workspace admin, with the chat:write scope.
+func WithUserToken(token string, options ...slack.Option) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.userClient = slack.New(token, options...)
+	}
+}
+
+// WithReplyDeletion deletes the Bot's replies to a command when the user deletes the command's message, and lets the
+// user delete a reply by reacting to it with :x:. This requires the app to subscribe to the message events of the
+// channels (e.g. message.channels) and to the reaction_added event, with the reactions:read scope.
+func WithReplyDeletion() BotOptionFunc {
+	return func(bot *Bot) {
+		bot.deleteReplies = true
+	}
+}
+
+// WithSessionTimeout sets how long a session (see StartSession) waits for the user's reply. The default is 5 minutes.
+func WithSessionTimeout(timeout time.Duration) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.sessions.timeout = timeout
+	}
+}
+
+// WithOversizePolicy sets how the Bot posts messages that exceed Slack's limits. The default is OversizeUpload.
+func WithOversizePolicy(policy OversizePolicy) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.oversize = policy
+	}
+}
+
+// WithStore sets the Store used by the Bot to keep its state (e.g. scheduled jobs) and made available to Handlers
+// through StoreFromContext. The default is a MemoryStore.
+func WithStore(store Store) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.store = store
+	}
+}
+
+// WithClientProvider sets how the Bot determines the Slack client to use for a workspace. By default, the Bot uses the
+// client passed to NewBot for all workspaces. To serve multiple workspaces, use NewTokenStoreClientProvider with the
+// TokenStore populated by an Installer. If token rotation is enabled, use NewTokenClientProvider with a TokenRefresher.
+func WithClientProvider(provider ClientProvider) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.clients = provider
+	}
+}
+
+// WithSchedule runs the command on the schedule and posts its output in the channel. The job uses the client passed
+// to NewBot. If the Bot serves multiple workspaces (see WithClientProvider), use WithWorkspaceSchedule instead.
+func WithSchedule(schedule Schedule, channel string, command ...string) BotOptionFunc {
+	return WithWorkspaceSchedule("", schedule, channel, command...)
+}
+
+// WithWorkspaceSchedule runs the command on the schedule and posts its output in the channel of the workspace.
+func WithWorkspaceSchedule(teamID string, schedule Schedule, channel string, command ...string) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.scheduler.add(schedule, teamID, channel, command...)
+	}
+}
+
+// WithScheduleCommand registers the "schedule" command, which lets users manage scheduled jobs:
+//
+//	schedule add <schedule> <channel> <command>
+//	schedule list
+//	schedule rm <id>
+//
+// E.g. `schedule add "0 9 * * 1-5" #ops report daily` runs the command "report daily" every weekday at 9:00 and posts
+// its output in #ops. See ParseSchedule for the supported schedules.
+func WithScheduleCommand() BotOptionFunc {
+	return func(bot *Bot) {
+		bot.Commands["schedule"] = bot.scheduleCommands()
+	}
+}
+
+// WithLocalization translates the Bot's replies in each user's language, using the catalog. The Bot uses the language
+// set by the user with the "language" command (see WithLanguageCommand) or, if not set, the user's locale in Slack.
+// Handlers translate their own messages with Translate.
+//
+// Looking up a user's locale in Slack requires the users:read scope.
+func WithLocalization(catalog Catalog) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.catalog = catalog
+	}
+}
+
+// WithLanguageCommand registers the "language" command, which lets users choose the language of the Bot's replies:
+//
+//	language
+//	language <locale>
+//	language auto
+//
+// E.g. "language fr" sets the user's language to French, while "language auto" reverts to the user's locale in Slack.
+func WithLanguageCommand() BotOptionFunc {
+	return func(bot *Bot) {
+		bot.Commands["language"] = HandlerFunc(bot.language)
+	}
+}
+
+// WithHomeRenderer publishes the view built by the renderer whenever a user opens the bot's App Home tab.
+func WithHomeRenderer(renderer HomeRenderer) BotOptionFunc {
+	return func(bot *Bot) {
+		bot.home = renderer
+	}
+}
+
+// WithDefaultHome publishes the Bot's default App Home view (see Bot.RenderHome) whenever a user opens the bot's App Home tab.
+func WithDefaultHome() BotOptionFunc {
+	return func(bot *Bot) {
+		bot.home = bot
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stripMention returns the tokens of the command in a message. If the message mentions the bot, the command follows
+// the bot's (first) mention and any text before the mention is ignored. Mentions of other users are passed to the
+// command as arguments.
+func stripMention(tokens []Token, botUserID string) []Token {
+	isBot := func(token Token) bool { return token.Kind == TokenUser && token.Target == botUserID }
+	if index := slices.IndexFunc(tokens, isBot); index >= 0 {
+		tokens = tokens[index+1:]
+	}
+	for len(tokens) > 0 && isBot(tokens[0]) {
+		tokens = tokens[1:]
 	}
-	return matches[1]
+	return tokens
 }
@@ -46,10 +46,77 @@ func TestBot(t *testing.T) {
 	post = <-ts.post
 	assert.Equal(t, `[{"color":"bad","title":"invalid command","text":"supported commands: foo","blocks":null}]`, post.Get("attachments"))
 
+	// no command
+	go b.SlackApp.socketModeHandler.(*testutils.FakeHandler).SendEvent(testutils.AppMentionEvent("<@W23456789>"), smClient)
+
+	post = <-ts.post
+	assert.Equal(t, `[{"title":"supported commands","text":"• foo","blocks":null}]`, post.Get("attachments"))
+
 	cancel()
 	assert.NoError(t, <-errCh)
 }
 
+func TestBot_Serialized(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 30)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	// handlers share state without locking: the Bot executes them one at a time
+	var count int
+	counter := HandlerFunc(func(_ context.Context, _ ...string) []slack.MsgOption {
+		count++
+		return []slack.MsgOption{slack.MsgOptionText("counted", false)}
+	})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var h testutils.FakeHandler
+	b := newBotWith(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")), &h,
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("count", counter),
+		WithAction("count", counter),
+		WithCommand("block", HandlerFunc(func(_ context.Context, _ ...string) []slack.MsgOption {
+			close(started)
+			<-release
+			return []slack.MsgOption{slack.MsgOptionText("released", false)}
+		})),
+	)
+
+	errCh := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { errCh <- b.Run(ctx) }()
+
+	slackClient := slack.New("", slack.OptionHTTPClient(&http.Client{Transport: &testutils.StubbedRoundTripper{}}))
+	smClient := socketmode.New(slackClient)
+
+	// slash commands are acknowledged while the Bot executes a command
+	go h.SendEvent(testutils.AppMentionEvent("<@W23456789> block"), smClient)
+	<-started
+	assert.Nil(t, b.onSlashCommand(ctx, slack.SlashCommand{Command: "/bot", Text: "count", ChannelID: "C1", UserID: "U1"}))
+	close(release)
+	assert.Equal(t, "released", (<-ts.post).Get("text"))
+	assert.Equal(t, "counted", (<-ts.post).Get("text"))
+
+	// commands, slash commands and actions don't run concurrently
+	const n = 5
+	for range n {
+		go h.SendEvent(testutils.AppMentionEvent("<@W23456789> count"), smClient)
+		go h.SendSlashCommand(testutils.SlashCommandEvent(slack.SlashCommand{Command: "/bot", Text: "count", ChannelID: "C1", UserID: "U1"}), smClient)
+		go h.SendInteraction(testutils.InteractionEvent(slack.InteractionCallback{
+			Type:           slack.InteractionTypeBlockActions,
+			User:           slack.User{ID: "U1"},
+			Channel:        slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}},
+			ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{{ActionID: "count"}}},
+		}), smClient)
+	}
+	for range 3 * n {
+		assert.Equal(t, "counted", (<-ts.post).Get("text"))
+	}
+
+	cancel()
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, 3*n+1, count)
+}
+
 func Test_tokenizeText(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -85,32 +152,52 @@ func Test_tokenizeText(t *testing.T) {
 	}
 }
 
-func Test_removeUserID(t *testing.T) {
+func Test_stripMention(t *testing.T) {
 	tests := []struct {
 		name  string
 		input string
-		want  string
+		want  []string
 	}{
 		{
 			name:  "no mention",
 			input: "foo",
-			want:  "foo",
+			want:  []string{"foo"},
+		},
+		{
+			name:  "mention",
+			input: "<@W23456789> foo",
+			want:  []string{"foo"},
 		},
 		{
-			name:  "match",
-			input: "<@U07V31R90R0> foo",
-			want:  "foo",
+			name:  "empty mention",
+			input: "<@W23456789> ",
+			want:  []string{},
 		},
 		{
-			name:  "match empty",
-			input: "<@U07V31R90R0> ",
-			want:  "",
+			name:  "mention in the middle",
+			input: "hey <@W23456789> foo bar",
+			want:  []string{"foo", "bar"},
+		},
+		{
+			name:  "multiple mentions",
+			input: "<@W23456789> <@W23456789|bot> foo <@W23456789>",
+			want:  []string{"foo", "<@W23456789>"},
+		},
+		{
+			name:  "other users",
+			input: "<@U123> <@W23456789> page <@U456>",
+			want:  []string{"page", "<@U456>"},
+		},
+		{
+			name:  "multiple lines",
+			input: "<@W23456789>\nfoo\n  bar",
+			want:  []string{"foo", "bar"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.want, removeUserID(tt.input))
+			assert.Equal(t, tt.want, arguments(stripMention(Tokenize(tt.input), "W23456789")))
 		})
 	}
 }
@@ -118,19 +205,54 @@ func Test_removeUserID(t *testing.T) {
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 type testServer struct {
-	t    *testing.T
-	post chan url.Values
+	t       *testing.T
+	post    chan url.Values
+	views   chan []byte
+	uploads chan url.Values
+	deletes chan url.Values
+	updates chan url.Values
+	content string
 }
 
 func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.URL.Path {
 	case "/auth.test":
 		_, _ = w.Write([]byte(`{ "ok": true, "url": "https://subarachnoid.slack.com/", "team": "Subarachnoid Workspace", "user": "bot", "team_id": "T0G9PQBBK", "user_id": "W23456789", "bot_id": "BZYBOTHED" }`))
-	case "/chat.postMessage":
+	case "/chat.postMessage", "/chat.postEphemeral":
 		body, _ := io.ReadAll(r.Body)
 		if values, err := url.ParseQuery(string(body)); err == nil {
 			s.post <- values
 		}
+		_, _ = w.Write([]byte(`{ "ok": true, "ts": "100.0" }`))
+	case "/conversations.open":
+		_, _ = w.Write([]byte(`{ "ok": true, "channel": { "id": "D1" } }`))
+	case "/files.getUploadURLExternal":
+		_, _ = w.Write([]byte(`{ "ok": true, "upload_url": "http://` + r.Host + `/upload", "file_id": "F1" }`))
+	case "/upload":
+		file, header, err := r.FormFile("file")
+		if err == nil {
+			content, _ := io.ReadAll(file)
+			s.content = header.Filename + ":" + string(content)
+		}
+	case "/files.completeUploadExternal":
+		_ = r.ParseForm()
+		s.uploads <- url.Values{"channel": {r.Form.Get("channel_id")}, "thread_ts": {r.Form.Get("thread_ts")}, "file": {s.content}}
+		_, _ = w.Write([]byte(`{ "ok": true, "files": [ { "id": "F1" } ] }`))
+	case "/chat.delete":
+		_ = r.ParseForm()
+		s.deletes <- url.Values{"channel": {r.Form.Get("channel")}, "ts": {r.Form.Get("ts")}, "token": {r.Form.Get("token")}}
+		_, _ = w.Write([]byte(`{ "ok": true }`))
+	case "/chat.update":
+		_ = r.ParseForm()
+		s.updates <- r.Form
+		_, _ = w.Write([]byte(`{ "ok": true, "ts": "` + r.Form.Get("ts") + `" }`))
+	case "/users.info":
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(`{ "ok": true, "user": { "id": "` + r.Form.Get("user") + `", "locale": "fr-FR" } }`))
+	case "/views.publish", "/views.open":
+		body, _ := io.ReadAll(r.Body)
+		s.views <- body
+		_, _ = w.Write([]byte(`{ "ok": true }`))
 	default:
 		s.t.Log(r.URL.String())
 		http.Error(w, "not found", http.StatusNotFound)
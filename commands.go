@@ -3,11 +3,13 @@ package slackapp
 import (
 	"context"
 	"github.com/slack-go/slack"
+	"maps"
 	"slices"
 	"strings"
+	"unicode/utf8"
 )
 
-// A Handler executes a command and returns messages to be posted to Slack.
+// A Handler executes a command and returns messages to be posted to Slack. A Bot calls its Handlers one at a time.
 type Handler interface {
 	Handle(context.Context, ...string) []slack.MsgOption
 }
@@ -35,17 +37,21 @@ var _ Handler = Commands{}
 type Commands map[string]Handler
 
 func (c Commands) Handle(ctx context.Context, args ...string) []slack.MsgOption {
-	if cmd, params := split(args...); cmd != "" {
-		if subCommand, ok := c[cmd]; ok {
-			return subCommand.Handle(ctx, params...)
+	cmd, params := split(args...)
+	if cmd != "" {
+		if verb, subCommand, ok := c.lookup(ctx, cmd); ok {
+			return subCommand.Handle(withCommandPath(ctx, verb), params...)
 		}
 	}
+	if ctx, resolved, ok := c.fallback(ctx, args...); ok {
+		return c.Handle(ctx, resolved...)
+	}
 
-	return []slack.MsgOption{slack.MsgOptionAttachments(slack.Attachment{
-		Color: "bad",
-		Title: "invalid command",
-		Text:  "supported commands: " + strings.Join(c.GetCommands(), ", "),
-	})}
+	text := Translate(ctx, "supported commands: %s", strings.Join(c.GetCommands(), ", "))
+	if suggestions := c.suggest(cmd); len(suggestions) > 0 {
+		text = Translate(ctx, "did you mean %s?", strings.Join(suggestions, " "+Translate(ctx, "or")+" ")) + "\n" + text
+	}
+	return errorMessage(ctx, "invalid command", text)
 }
 
 func split(args ...string) (string, []string) {
@@ -58,11 +64,13 @@ func split(args ...string) (string, []string) {
 	return args[0], args[1:]
 }
 
-// GetCommands returns a sorted list of all supported commands.
+// GetCommands returns a sorted list of all supported commands. Aliases are not included.
 func (c Commands) GetCommands() []string {
 	commands := make([]string, 0, len(c))
-	for verb := range c {
-		commands = append(commands, verb)
+	for verb, handler := range c {
+		if _, ok := handler.(alias); !ok {
+			commands = append(commands, verb)
+		}
 	}
 	slices.Sort(commands)
 	return commands
@@ -74,3 +82,190 @@ func (c Commands) Add(commands Commands) {
 		c[verb] = handler
 	}
 }
+
+// Alias registers one or more aliases for the verb: e.g. after c.Alias("deploy", "dep"), the command "dep" executes
+// the "deploy" command. Handlers see the verb, not the alias, in their CommandPath.
+func (c Commands) Alias(verb string, aliases ...string) {
+	for _, name := range aliases {
+		c[name] = alias(verb)
+	}
+}
+
+// alias is registered in Commands for each alias of a verb. Commands resolves it to the verb's handler.
+type alias string
+
+// Handle is never called: Commands resolves the alias to the verb's handler.
+func (a alias) Handle(_ context.Context, _ ...string) []slack.MsgOption {
+	return nil
+}
+
+// lookup finds the handler for the command, following aliases and applying the Matching recorded in the context. It
+// returns the matched verb and its handler.
+func (c Commands) lookup(ctx context.Context, cmd string) (string, Handler, bool) {
+	if cmd == "" {
+		return "", nil, false
+	}
+	if _, ok := c[cmd]; ok {
+		return c.follow(cmd)
+	}
+	matching := matchingFromContext(ctx)
+	normalize := func(s string) string { return s }
+	if matching&MatchCaseInsensitive != 0 {
+		normalize = strings.ToLower
+	}
+	cmd = normalize(cmd)
+	if verb, ok := c.unique(func(name string) bool { return normalize(name) == cmd }); ok {
+		return c.follow(verb)
+	}
+	if matching&MatchPrefix != 0 {
+		if verb, ok := c.unique(func(name string) bool { return strings.HasPrefix(normalize(name), cmd) }); ok {
+			return c.follow(verb)
+		}
+	}
+	return "", nil, false
+}
+
+// unique returns the verb matched by the function, if exactly one verb (or its aliases) matches.
+func (c Commands) unique(match func(string) bool) (string, bool) {
+	var found string
+	for name := range c {
+		if !match(name) {
+			continue
+		}
+		verb, _, ok := c.follow(name)
+		if !ok || (found != "" && found != verb) {
+			return "", false
+		}
+		found = verb
+	}
+	return found, found != ""
+}
+
+// follow returns the verb and handler registered under name, resolving aliases.
+func (c Commands) follow(name string) (string, Handler, bool) {
+	handler, ok := c[name]
+	if target, isAlias := handler.(alias); isAlias {
+		name = string(target)
+		handler, ok = c[name]
+		if _, isAlias = handler.(alias); isAlias {
+			return "", nil, false
+		}
+	}
+	return name, handler, ok
+}
+
+// maxSuggestions is the maximum number of suggestions made for an invalid command.
+const maxSuggestions = 3
+
+// suggest returns the verbs that are close to the invalid command (based on their Levenshtein distance), closest first.
+func (c Commands) suggest(cmd string) []string {
+	if cmd == "" {
+		return nil
+	}
+	cmd = strings.ToLower(cmd)
+	maxDistance := max(1, min(2, utf8.RuneCountInString(cmd)/3))
+	distances := make(map[string]int)
+	for name := range c {
+		verb, _, ok := c.follow(name)
+		if !ok {
+			continue
+		}
+		distance := levenshtein(cmd, strings.ToLower(name))
+		if strings.HasPrefix(strings.ToLower(name), cmd) {
+			distance = 0
+		}
+		if current, found := distances[verb]; distance <= maxDistance && (!found || distance < current) {
+			distances[verb] = distance
+		}
+	}
+	suggestions := slices.Collect(maps.Keys(distances))
+	slices.SortFunc(suggestions, func(a, b string) int {
+		if distances[a] != distances[b] {
+			return distances[a] - distances[b]
+		}
+		return strings.Compare(a, b)
+	})
+	return suggestions[:min(len(suggestions), maxSuggestions)]
+}
+
+// levenshtein returns the minimum number of single-character edits needed to change a into b.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return row[len(t)]
+}
+
+// Matching determines how Commands matches a verb, in addition to an exact match. Options can be combined,
+// e.g. MatchCaseInsensitive|MatchPrefix.
+type Matching int
+
+const (
+	// MatchCaseInsensitive matches verbs regardless of case: "Deploy" matches "deploy".
+	MatchCaseInsensitive Matching = 1 << iota
+	// MatchPrefix matches a verb by its prefix: "dep" matches "deploy", unless another verb also starts with "dep".
+	MatchPrefix
+)
+
+type matchingKey struct{}
+
+// withMatching records how Commands should match verbs in the context.
+func withMatching(ctx context.Context, matching Matching) context.Context {
+	return context.WithValue(ctx, matchingKey{}, matching)
+}
+
+func matchingFromContext(ctx context.Context) Matching {
+	matching, _ := ctx.Value(matchingKey{}).(Matching)
+	return matching
+}
+
+// resolve finds the handler for the provided arguments. It returns the handler, the command path leading to it and
+// the remaining arguments. If no handler is found, it returns nil.
+func (c Commands) resolve(ctx context.Context, args ...string) (Handler, []string, []string) {
+	var path []string
+	var handler Handler = c
+	for {
+		var commands Commands
+		switch h := handler.(type) {
+		case Commands:
+			commands = h
+		case *Commands:
+			commands = *h
+		default:
+			return handler, path, args
+		}
+		cmd, params := split(args...)
+		verb, subCommand, ok := commands.lookup(ctx, cmd)
+		if !ok {
+			return nil, path, args
+		}
+		handler, path, args = subCommand, append(path, verb), params
+	}
+}
+
+type commandPathKey struct{}
+
+// withCommandPath records that the verb was matched in the context.
+func withCommandPath(ctx context.Context, verb string) context.Context {
+	return context.WithValue(ctx, commandPathKey{}, append(slices.Clone(CommandPath(ctx)), verb))
+}
+
+// CommandPath returns the verbs that were matched to reach the current handler, e.g. ["bar", "snafu"] for the
+// command "bar snafu".
+func CommandPath(ctx context.Context) []string {
+	path, _ := ctx.Value(commandPathKey{}).([]string)
+	return path
+}
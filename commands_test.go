@@ -109,3 +109,118 @@ func formatMessage(options []slack.MsgOption) url.Values {
 
 	return values
 }
+
+func TestCommands_Matching(t *testing.T) {
+	handler := HandlerFunc(func(ctx context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(append(CommandPath(ctx), args...), " "), true)}
+	})
+	c := Commands{
+		"deploy":  handler,
+		"destroy": handler,
+		"status":  handler,
+		"app":     &Commands{"restart": handler, "rollback": handler},
+	}
+	c.Alias("deploy", "dep", "ship")
+	(*c["app"].(*Commands)).Alias("restart", "bounce")
+
+	tests := []struct {
+		name     string
+		matching Matching
+		args     []string
+		want     map[string]string
+	}{
+		{
+			name: "alias",
+			args: []string{"ship", "prod"},
+			want: map[string]string{"text": "deploy prod"},
+		},
+		{
+			name: "nested alias",
+			args: []string{"app", "bounce"},
+			want: map[string]string{"text": "app restart"},
+		},
+		{
+			name: "exact match is case-sensitive",
+			args: []string{"Status"},
+			want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"did you mean status?\nsupported commands: app, deploy, destroy, status","blocks":null}]`},
+		},
+		{
+			name:     "case-insensitive",
+			matching: MatchCaseInsensitive,
+			args:     []string{"Status"},
+			want:     map[string]string{"text": "status"},
+		},
+		{
+			name: "prefix matching is disabled by default",
+			args: []string{"sta"},
+			want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"did you mean status?\nsupported commands: app, deploy, destroy, status","blocks":null}]`},
+		},
+		{
+			name:     "unique prefix",
+			matching: MatchPrefix,
+			args:     []string{"sta"},
+			want:     map[string]string{"text": "status"},
+		},
+		{
+			name:     "nested prefix",
+			matching: MatchPrefix | MatchCaseInsensitive,
+			args:     []string{"AP", "res", "now"},
+			want:     map[string]string{"text": "app restart now"},
+		},
+		{
+			name:     "alias matches before prefix",
+			matching: MatchPrefix,
+			args:     []string{"dep"},
+			want:     map[string]string{"text": "deploy"},
+		},
+		{
+			name:     "ambiguous prefix",
+			matching: MatchPrefix,
+			args:     []string{"de"},
+			want:     map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"did you mean deploy or destroy?\nsupported commands: app, deploy, destroy, status","blocks":null}]`},
+		},
+		{
+			name: "typo",
+			args: []string{"deplyo"},
+			want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"did you mean deploy?\nsupported commands: app, deploy, destroy, status","blocks":null}]`},
+		},
+		{
+			name: "nested typo",
+			args: []string{"app", "rolback"},
+			want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"did you mean rollback?\nsupported commands: restart, rollback","blocks":null}]`},
+		},
+		{
+			name: "no suggestion",
+			args: []string{"foobar"},
+			want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"supported commands: app, deploy, destroy, status","blocks":null}]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := formatMessage(c.Handle(withMatching(context.Background(), tt.matching), tt.args...))
+			for k, v := range tt.want {
+				require.Contains(t, output, k)
+				assert.Equal(t, v, output.Get(k))
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "", b: "foo", want: 3},
+		{a: "foo", b: "foo", want: 0},
+		{a: "deplyo", b: "deploy", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "café", b: "cafe", want: 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, levenshtein(tt.a, tt.b), tt.a+"/"+tt.b)
+		assert.Equal(t, tt.want, levenshtein(tt.b, tt.a), tt.b+"/"+tt.a)
+	}
+}
@@ -0,0 +1,289 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A ConnectionState is the state of the SlackApp's Socket Mode connection.
+type ConnectionState int
+
+const (
+	// StateDisconnected means the SlackApp isn't connected to Slack.
+	StateDisconnected ConnectionState = iota
+	// StateConnecting means the SlackApp is connecting to Slack.
+	StateConnecting
+	// StateConnected means the SlackApp is connected to Slack.
+	StateConnected
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int(s))
+	}
+}
+
+// ConnectionStats is a snapshot of the SlackApp's connection. See SlackApp.Stats.
+type ConnectionStats struct {
+	State ConnectionState
+	// Uptime is the time since the SlackApp connected, or zero if it isn't connected.
+	Uptime time.Duration
+	// Downtime is the time since the SlackApp lost its connection (or started connecting), or zero if it's connected.
+	Downtime time.Duration
+	// Reconnects is the number of times the SlackApp connected to Slack after its first connection.
+	Reconnects int
+	// LastError is the last connection error, and LastErrorTime is when it occurred.
+	LastError     error
+	LastErrorTime time.Time
+}
+
+// Backoff determines how long SlackApp waits before reconnecting to Slack after the Socket Mode connection failed or
+// was closed. The n-th consecutive attempt waits Initial * Multiplier^(n-1), up to Max. Jitter randomizes each wait by
+// up to the provided fraction (e.g. 0.2 for ±20%), so multiple instances don't reconnect at the same time.
+//
+// Once a connection is established, the next failure starts over at Initial. SlackApp doesn't reconnect if Slack
+// rejected the app token (e.g. invalid_auth or token_revoked): Run returns the error instead.
+type Backoff struct {
+	// MaxRetries is the number of consecutive failed attempts after which Run returns the error. Zero (the default)
+	// retries indefinitely.
+	MaxRetries int
+	// Initial is the wait before the first attempt. Default: 1 second.
+	Initial time.Duration
+	// Max is the maximum wait between attempts. Default: 5 minutes.
+	Max time.Duration
+	// Multiplier is the factor by which the wait increases after each attempt. Default: 2.
+	Multiplier float64
+	// Jitter is the fraction by which each wait is randomized. Default: no jitter.
+	Jitter float64
+}
+
+// wait returns how long to wait before the n-th attempt, starting at 1.
+func (b Backoff) wait(attempt int) time.Duration {
+	initial := orDefault(b.Initial, time.Second)
+	maxWait := orDefault(b.Max, 5*time.Minute)
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	wait := min(float64(initial)*math.Pow(multiplier, float64(attempt-1)), float64(maxWait))
+	if b.Jitter > 0 {
+		wait *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(wait)
+}
+
+func orDefault(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// isAuthError returns true if Slack rejected the app token. Reconnecting won't fix that.
+func isAuthError(err error) bool {
+	var statusErr slack.StatusCodeError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+		return true
+	}
+	switch err.Error() {
+	case "invalid_auth", "account_inactive", "not_authed", "token_revoked":
+		return true
+	default:
+		return false
+	}
+}
+
+// connection tracks the state of the SlackApp's connection.
+type connection struct {
+	state         ConnectionState
+	connects      int
+	connectedAt   time.Time
+	downSince     time.Time
+	lastError     error
+	lastErrorTime time.Time
+	alarm         *time.Timer
+	// cancel stops the running event loop. It's nil if no event loop is running.
+	cancel context.CancelFunc
+	// restarted is set when the event loop was stopped to reconnect, and reason holds the error that caused it.
+	restarted bool
+	reason    error
+	lock      sync.Mutex
+	// transitions serializes state changes, so OnStateChange is called in the order the changes occurred.
+	transitions sync.Mutex
+}
+
+// setState records the connection's new state and notifies OnStateChange of any change. When the connection goes down,
+// it arms the downtime alarm. When it's connected, it disarms it.
+//
+// Socket Mode dispatches each event in its own goroutine, so an event of an event loop that already stopped may arrive
+// late. Only the Disconnected state is recorded while no event loop is running.
+func (h *SlackApp) setState(state ConnectionState) {
+	h.connection.transitions.Lock()
+	defer h.connection.transitions.Unlock()
+
+	h.connection.lock.Lock()
+	if state != StateDisconnected && h.connection.cancel == nil {
+		h.connection.lock.Unlock()
+		return
+	}
+	previous := h.connection.state
+	h.connection.state = state
+	now := time.Now()
+	switch {
+	case state == StateConnected && previous != StateConnected:
+		h.connection.connects++
+		h.connection.connectedAt = now
+		h.connection.downSince = time.Time{}
+		if h.connection.alarm != nil {
+			h.connection.alarm.Stop()
+			h.connection.alarm = nil
+		}
+	case state != StateConnected && h.connection.downSince.IsZero():
+		h.connection.downSince = now
+		h.armDowntimeAlarm()
+	}
+	h.connection.lock.Unlock()
+
+	if state != previous && h.OnStateChange != nil {
+		h.OnStateChange(state)
+	}
+}
+
+// armDowntimeAlarm calls OnDowntime if the SlackApp doesn't reconnect within MaxDowntime. The caller must hold the
+// connection's lock.
+func (h *SlackApp) armDowntimeAlarm() {
+	if h.MaxDowntime <= 0 || h.OnDowntime == nil || h.connection.alarm != nil {
+		return
+	}
+	since := h.connection.downSince
+	h.connection.alarm = time.AfterFunc(h.MaxDowntime, func() {
+		downtime := time.Since(since)
+		h.logger.Error("Slack connection down for too long", "downtime", downtime)
+		h.OnDowntime(downtime)
+	})
+}
+
+// setError records a connection error.
+func (h *SlackApp) setError(err error) {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	h.connection.lastError = err
+	h.connection.lastErrorTime = time.Now()
+}
+
+// Stats returns a snapshot of the SlackApp's connection.
+func (h *SlackApp) Stats() ConnectionStats {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	stats := ConnectionStats{
+		State:         h.connection.state,
+		Reconnects:    max(h.connection.connects-1, 0),
+		LastError:     h.connection.lastError,
+		LastErrorTime: h.connection.lastErrorTime,
+	}
+	if h.connection.state == StateConnected {
+		stats.Uptime = time.Since(h.connection.connectedAt)
+	} else if !h.connection.downSince.IsZero() {
+		stats.Downtime = time.Since(h.connection.downSince)
+	}
+	return stats
+}
+
+// supervise runs the Socket Mode event loop. When the connection fails or is closed, it stops the event loop and
+// starts a new one as per the Reconnect policy, rather than leaving it to Socket Mode's own retries.
+func (h *SlackApp) supervise(ctx context.Context) error {
+	defer h.stopDowntimeAlarm()
+	for attempt := 1; ; attempt++ {
+		connects := h.connectCount()
+		runCtx, cancel := context.WithCancel(ctx)
+		h.startRun(cancel)
+		h.setState(StateConnecting)
+		err := h.socketModeHandler.RunEventLoopContext(runCtx)
+		if restarted, reason := h.stopRun(); restarted {
+			err = reason
+		} else if err != nil {
+			h.setError(err)
+		}
+		h.setState(StateDisconnected)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && isAuthError(err) {
+			return err
+		}
+		// if the connection was (re-)established, the backoff starts over
+		if h.connectCount() > connects {
+			attempt = 1
+		}
+		if h.Reconnect.MaxRetries > 0 && attempt > h.Reconnect.MaxRetries {
+			return err
+		}
+		wait := h.Reconnect.wait(attempt)
+		h.logger.Warn("Slack connection lost. reconnecting", "err", err, "attempt", attempt, "wait", wait)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// startRun records the function that stops the event loop that is about to start.
+func (h *SlackApp) startRun(cancel context.CancelFunc) {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	h.connection.cancel = cancel
+	h.connection.restarted = false
+	h.connection.reason = nil
+}
+
+// stopRun stops the event loop. It returns true if the event loop was stopped to reconnect, and the reason why.
+func (h *SlackApp) stopRun() (bool, error) {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	h.connection.cancel()
+	h.connection.cancel = nil
+	return h.connection.restarted, h.connection.reason
+}
+
+// restart stops the running event loop, so supervise can reconnect as per the Reconnect policy.
+func (h *SlackApp) restart(reason error) {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	if h.connection.cancel == nil {
+		return
+	}
+	h.connection.restarted = true
+	h.connection.reason = reason
+	h.connection.cancel()
+}
+
+// connectCount returns the number of times the SlackApp connected to Slack.
+func (h *SlackApp) connectCount() int {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	return h.connection.connects
+}
+
+func (h *SlackApp) stopDowntimeAlarm() {
+	h.connection.lock.Lock()
+	defer h.connection.lock.Unlock()
+	if h.connection.alarm != nil {
+		h.connection.alarm.Stop()
+		h.connection.alarm = nil
+	}
+}
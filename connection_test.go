@@ -0,0 +1,208 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/slackapp/internal/testutils"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff_wait(t *testing.T) {
+	var b Backoff
+	assert.Equal(t, time.Second, b.wait(1))
+	assert.Equal(t, 4*time.Second, b.wait(3))
+	assert.Equal(t, 5*time.Minute, b.wait(20))
+
+	b = Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 3}
+	assert.Equal(t, 300*time.Millisecond, b.wait(2))
+	assert.Equal(t, time.Second, b.wait(4))
+
+	b.Jitter = 0.5
+	for range 100 {
+		wait := b.wait(1)
+		assert.GreaterOrEqual(t, wait, 50*time.Millisecond)
+		assert.LessOrEqual(t, wait, 150*time.Millisecond)
+	}
+}
+
+func TestConnectionState_String(t *testing.T) {
+	assert.Equal(t, "disconnected", StateDisconnected.String())
+	assert.Equal(t, "connecting", StateConnecting.String())
+	assert.Equal(t, "connected", StateConnected.String())
+	assert.Equal(t, "ConnectionState(-1)", ConnectionState(-1).String())
+}
+
+// scriptedHandler is a socketModeHandler that runs the next function of its script each time the event loop is started.
+type scriptedHandler struct {
+	testutils.FakeHandler
+	script []func(ctx context.Context) error
+	runs   int
+}
+
+func (s *scriptedHandler) RunEventLoopContext(ctx context.Context) error {
+	run := s.script[min(s.runs, len(s.script)-1)]
+	s.runs++
+	return run(ctx)
+}
+
+type stateRecorder struct {
+	states []ConnectionState
+	lock   sync.Mutex
+}
+
+func (r *stateRecorder) record(state ConnectionState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.states = append(r.states, state)
+}
+
+func (r *stateRecorder) get() []ConnectionState {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.states
+}
+
+func TestSlackApp_Reconnect(t *testing.T) {
+	errConnection := errors.New("connection refused")
+	// connectionError reports a connection error, as Socket Mode does before retrying, and waits to be stopped.
+	connectionError := func(app **SlackApp) func(context.Context) error {
+		return func(ctx context.Context) error {
+			(*app).onConnectionError(&socketmode.Event{Type: socketmode.EventTypeConnectionError, Data: &slack.ConnectionErrorEvent{ErrorObj: errConnection}}, nil)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+	}
+
+	t.Run("auth error", func(t *testing.T) {
+		errAuth := errors.New("invalid_auth")
+		h := scriptedHandler{script: []func(context.Context) error{func(context.Context) error { return errAuth }}}
+		app := newSlackAppWithSocketModeHandler(nil, &h, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		var r stateRecorder
+		app.OnStateChange = r.record
+
+		assert.ErrorIs(t, app.Run(context.Background()), errAuth)
+		assert.Equal(t, 1, h.runs)
+		assert.Equal(t, []ConnectionState{StateConnecting, StateDisconnected}, r.get())
+		stats := app.Stats()
+		assert.Equal(t, StateDisconnected, stats.State)
+		assert.ErrorIs(t, stats.LastError, errAuth)
+		assert.False(t, stats.LastErrorTime.IsZero())
+
+		// late events of a stopped event loop don't change the state
+		app.onConnected(nil, nil)
+		assert.Equal(t, StateDisconnected, app.Stats().State)
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		var app *SlackApp
+		h := scriptedHandler{script: []func(context.Context) error{connectionError(&app)}}
+		app = newSlackAppWithSocketModeHandler(nil, &h, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		app.Reconnect = Backoff{MaxRetries: 2, Initial: time.Millisecond}
+
+		assert.ErrorIs(t, app.Run(context.Background()), errConnection)
+		assert.Equal(t, 3, h.runs)
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		connected := make(chan struct{})
+		var app *SlackApp
+		h := scriptedHandler{script: []func(context.Context) error{
+			connectionError(&app),
+			func(ctx context.Context) error {
+				app.onConnecting(nil, nil)
+				app.onConnected(nil, nil)
+				app.onDisconnected(nil, nil)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			connectionError(&app),
+			func(ctx context.Context) error {
+				app.onConnecting(nil, nil)
+				app.onConnected(nil, nil)
+				close(connected)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}}
+		app = newSlackAppWithSocketModeHandler(nil, &h, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		// a connection that was established resets the backoff, so two retries are enough to survive three failures
+		app.Reconnect = Backoff{MaxRetries: 2, Initial: time.Millisecond}
+		var r stateRecorder
+		app.OnStateChange = r.record
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := make(chan error)
+		go func() { errCh <- app.Run(ctx) }()
+
+		<-connected
+		stats := app.Stats()
+		assert.Equal(t, StateConnected, stats.State)
+		assert.Equal(t, 1, stats.Reconnects)
+		assert.Zero(t, stats.Downtime)
+		assert.ErrorIs(t, stats.LastError, errConnection)
+		assert.True(t, app.Connected())
+
+		cancel()
+		require.NoError(t, <-errCh)
+		assert.Equal(t, 4, h.runs)
+		assert.Equal(t, []ConnectionState{
+			StateConnecting, StateDisconnected,
+			StateConnecting, StateConnected, StateDisconnected,
+			StateConnecting, StateDisconnected,
+			StateConnecting, StateConnected, StateDisconnected,
+		}, r.get())
+	})
+}
+
+func TestSlackApp_setState(t *testing.T) {
+	app := newSlackAppWithSocketModeHandler(nil, &scriptedHandler{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	app.startRun(func() {})
+	// OnStateChange isn't called concurrently, and is called before the state changes again
+	var states []ConnectionState
+	app.OnStateChange = func(state ConnectionState) {
+		states = append(states, state)
+		assert.Equal(t, state, app.Stats().State)
+	}
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.setState([]ConnectionState{StateConnected, StateDisconnected}[i%2])
+		}()
+	}
+	wg.Wait()
+	assert.NotEmpty(t, states)
+}
+
+func TestSlackApp_OnDowntime(t *testing.T) {
+	h := scriptedHandler{script: []func(context.Context) error{func(context.Context) error { return errors.New("fail") }}}
+	app := newSlackAppWithSocketModeHandler(nil, &h, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	app.Reconnect = Backoff{Initial: 10 * time.Millisecond, Max: 10 * time.Millisecond}
+	app.MaxDowntime = 50 * time.Millisecond
+	alarms := make(chan time.Duration, 10)
+	app.OnDowntime = func(downtime time.Duration) { alarms <- downtime }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() { errCh <- app.Run(ctx) }()
+
+	downtime := <-alarms
+	assert.GreaterOrEqual(t, downtime, app.MaxDowntime)
+	assert.GreaterOrEqual(t, app.Stats().Downtime, app.MaxDowntime)
+
+	// the alarm fires once per outage
+	time.Sleep(2 * app.MaxDowntime)
+	assert.Empty(t, alarms)
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
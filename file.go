@@ -0,0 +1,61 @@
+package slackapp
+
+import (
+	"bytes"
+	"context"
+	"github.com/slack-go/slack"
+	"mime"
+	"path"
+)
+
+// A File is a file uploaded by the Bot, e.g. a chart or a CSV export. Add Files to a Response to upload them along
+// with the message:
+//
+//	Respond(ctx, Public(slack.MsgOptionText("CPU usage", false)).WithFiles(slackapp.File{Name: "cpu.png", Type: "image/png", Content: chart}))
+//
+// Uploading files requires the files:write scope.
+type File struct {
+	// Name is the name of the file, e.g. "cpu.png".
+	Name string
+	// Type is the MIME type of the file, e.g. "image/png". If the Name doesn't have an extension, the Bot adds the
+	// extension for the Type, so Slack can determine the type of the file.
+	Type string
+	// Title is the title of the file. The default is the file's Name.
+	Title string
+	// AltText describes an image, for users of screen readers.
+	AltText string
+	// Content holds the content of the file.
+	Content []byte
+}
+
+// Files returns a Response that uploads the files in the channel where the command was issued.
+func Files(files ...File) Response {
+	return Public().WithFiles(files...)
+}
+
+// filename returns the name of the file, with the extension for the file's type if the name doesn't have an extension.
+func (f File) filename() string {
+	name := f.Name
+	if name == "" {
+		name = "file"
+	}
+	if path.Ext(name) == "" && f.Type != "" {
+		if extensions, _ := mime.ExtensionsByType(f.Type); len(extensions) > 0 {
+			name += extensions[0]
+		}
+	}
+	return name
+}
+
+func uploadFile(ctx context.Context, client *slack.Client, channel string, threadTS string, file File) error {
+	_, err := client.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Channel:         channel,
+		ThreadTimestamp: threadTS,
+		Filename:        file.filename(),
+		Title:           file.Title,
+		AltTxt:          file.AltText,
+		FileSize:        len(file.Content),
+		Reader:          bytes.NewReader(file.Content),
+	})
+	return err
+}
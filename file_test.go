@@ -0,0 +1,58 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFile_filename(t *testing.T) {
+	tests := []struct {
+		file File
+		want string
+	}{
+		{file: File{Name: "chart.png", Type: "image/png"}, want: "chart.png"},
+		{file: File{Name: "chart", Type: "image/png"}, want: "chart.png"},
+		{file: File{Name: "export.data", Type: "text/csv"}, want: "export.data"},
+		{file: File{Name: "export"}, want: "export"},
+		{file: File{Type: "image/png"}, want: "file.png"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.file.filename())
+	}
+}
+
+func TestBot_run_Files(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), uploads: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	err := b.run(context.Background(), Request{ChannelID: "C1", UserID: "U1"}, func(ctx context.Context) []slack.MsgOption {
+		Respond(ctx,
+			Public(slack.MsgOptionText("cpu usage", false), slack.MsgOptionTS("1.0")).WithFiles(File{Name: "cpu", Type: "image/png", Content: []byte("png")}),
+			Files(File{Name: "export.csv", Content: []byte("a,b")}),
+		)
+		return nil
+	})
+	require.NoError(t, err)
+
+	post := <-ts.post
+	assert.Equal(t, "cpu usage", post.Get("text"))
+	assert.Equal(t, url.Values{"channel": {"C1"}, "thread_ts": {"1.0"}, "file": {"cpu.png:png"}}, <-ts.uploads)
+	assert.Equal(t, url.Values{"channel": {"C1"}, "thread_ts": {""}, "file": {"export.csv:a,b"}}, <-ts.uploads)
+	assert.Empty(t, ts.post)
+
+	// files can't be ephemeral
+	err = b.run(context.Background(), Request{ChannelID: "C1", UserID: "U1"}, func(ctx context.Context) []slack.MsgOption {
+		Respond(ctx, Ephemeral().WithFiles(File{Name: "export.csv", Content: []byte("a,b")}))
+		return nil
+	})
+	assert.ErrorIs(t, err, errEphemeralFile)
+}
@@ -0,0 +1,142 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Filter transforms the lines of a command's output. Users apply filters by piping the output of a command through
+// them, e.g. "logs api | grep error | tail 20".
+type Filter interface {
+	Filter(lines []string, args ...string) ([]string, error)
+}
+
+// FilterFunc is an adapter that allows a function to be used as a Filter
+type FilterFunc func(lines []string, args ...string) ([]string, error)
+
+// Filter calls f(lines, args)
+func (f FilterFunc) Filter(lines []string, args ...string) ([]string, error) {
+	return f(lines, args...)
+}
+
+// Filters returns the built-in filters:
+//
+//	grep [-i] [-v] <regexp>   lines matching the regular expression (-i: ignore case, -v: lines not matching)
+//	head [n]                  the first n lines (default: 10)
+//	tail [n]                  the last n lines (default: 10)
+//	sort [-r]                 the lines in (reverse) alphabetical order
+//	uniq                      the lines, with adjacent duplicate lines removed
+//	wc                        the number of lines
+func Filters() map[string]Filter {
+	return map[string]Filter{
+		"grep": FilterFunc(grep),
+		"head": FilterFunc(func(lines []string, args ...string) ([]string, error) {
+			n, err := lineCount(args...)
+			return lines[:min(n, len(lines))], err
+		}),
+		"tail": FilterFunc(func(lines []string, args ...string) ([]string, error) {
+			n, err := lineCount(args...)
+			return lines[len(lines)-min(n, len(lines)):], err
+		}),
+		"sort": FilterFunc(func(lines []string, args ...string) ([]string, error) {
+			if len(args) > 1 || (len(args) == 1 && args[0] != "-r") {
+				return nil, errors.New("usage: sort [-r]")
+			}
+			lines = slices.Clone(lines)
+			slices.Sort(lines)
+			if len(args) == 1 {
+				slices.Reverse(lines)
+			}
+			return lines, nil
+		}),
+		"uniq": FilterFunc(func(lines []string, _ ...string) ([]string, error) {
+			return slices.Compact(slices.Clone(lines)), nil
+		}),
+		"wc": FilterFunc(func(lines []string, _ ...string) ([]string, error) {
+			return []string{strconv.Itoa(len(lines))}, nil
+		}),
+	}
+}
+
+func grep(lines []string, args ...string) ([]string, error) {
+	var ignoreCase, invert bool
+	for len(args) > 1 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-i":
+			ignoreCase = true
+		case "-v":
+			invert = true
+		default:
+			return nil, fmt.Errorf("invalid option: %s", args[0])
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		return nil, errors.New("usage: grep [-i] [-v] <regexp>")
+	}
+	pattern := args[0]
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var output []string
+	for _, line := range lines {
+		if re.MatchString(line) != invert {
+			output = append(output, line)
+		}
+	}
+	return output, nil
+}
+
+const defaultLineCount = 10
+
+func lineCount(args ...string) (int, error) {
+	switch len(args) {
+	case 0:
+		return defaultLineCount, nil
+	case 1:
+		if n, err := strconv.Atoi(args[0]); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	return 0, errors.New("usage: [n]")
+}
+
+// filter pipes the text of the output through the filters. If the text is a code block, the filters are applied to
+// the content of the code block. Options that don't set the message's content are dropped.
+func (b *Bot) filter(ctx context.Context, output []slack.MsgOption, filters [][]string) []slack.MsgOption {
+	m, err := parseMessage(output...)
+	if err != nil {
+		b.logger.Warn("failed to parse command output", "err", err)
+		return output
+	}
+	text, isCode := codeBlockContent(m.text)
+	var lines []string
+	if text != "" {
+		lines = strings.Split(text, "\n")
+	}
+	for _, args := range filters {
+		f, ok := b.filters[args[0]]
+		if !ok {
+			return errorMessage(ctx, "invalid filter", Translate(ctx, "supported filters: %s", strings.Join(slices.Sorted(maps.Keys(b.filters)), ", ")))
+		}
+		if lines, err = f.Filter(lines, args[1:]...); err != nil {
+			return errorMessage(ctx, "invalid filter", args[0]+": "+err.Error())
+		}
+	}
+	m.text = strings.Join(lines, "\n")
+	if isCode {
+		m.text = codeBlock(m.text)
+	}
+	return m.options()
+}
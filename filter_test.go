@@ -0,0 +1,99 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	lines := []string{"b error", "a info", "c ERROR", "c ERROR", "d debug"}
+	tests := []struct {
+		filter  string
+		args    []string
+		want    []string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{filter: "grep", args: []string{"error"}, want: []string{"b error"}, wantErr: assert.NoError},
+		{filter: "grep", args: []string{"-i", "error"}, want: []string{"b error", "c ERROR", "c ERROR"}, wantErr: assert.NoError},
+		{filter: "grep", args: []string{"-v", "-i", "error"}, want: []string{"a info", "d debug"}, wantErr: assert.NoError},
+		{filter: "grep", args: []string{"-x", "error"}, wantErr: assert.Error},
+		{filter: "grep", args: []string{"("}, wantErr: assert.Error},
+		{filter: "grep", wantErr: assert.Error},
+		{filter: "head", args: []string{"2"}, want: []string{"b error", "a info"}, wantErr: assert.NoError},
+		{filter: "head", want: lines, wantErr: assert.NoError},
+		{filter: "head", args: []string{"-1"}, want: []string{}, wantErr: assert.Error},
+		{filter: "tail", args: []string{"2"}, want: []string{"c ERROR", "d debug"}, wantErr: assert.NoError},
+		{filter: "tail", args: []string{"20"}, want: lines, wantErr: assert.NoError},
+		{filter: "sort", want: []string{"a info", "b error", "c ERROR", "c ERROR", "d debug"}, wantErr: assert.NoError},
+		{filter: "sort", args: []string{"-r"}, want: []string{"d debug", "c ERROR", "c ERROR", "b error", "a info"}, wantErr: assert.NoError},
+		{filter: "sort", args: []string{"-x"}, wantErr: assert.Error},
+		{filter: "uniq", want: []string{"b error", "a info", "c ERROR", "d debug"}, wantErr: assert.NoError},
+		{filter: "wc", want: []string{"5"}, wantErr: assert.NoError},
+	}
+
+	filters := Filters()
+	for _, tt := range tests {
+		t.Run(strings.Join(append([]string{tt.filter}, tt.args...), " "), func(t *testing.T) {
+			output, err := filters[tt.filter].Filter(lines, tt.args...)
+			tt.wantErr(t, err)
+			if err == nil {
+				assert.Equal(t, tt.want, output)
+			}
+		})
+	}
+}
+
+func TestBot_Pipelines(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithPipelines(),
+		WithFilter("upper", FilterFunc(func(lines []string, _ ...string) ([]string, error) {
+			for i := range lines {
+				lines[i] = strings.ToUpper(lines[i])
+			}
+			return lines, nil
+		})),
+		WithCommand("logs", HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("1 error\n2 info\n3 error\n4 debug", false)}
+		})),
+		WithCommand("status", HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{
+				slack.MsgOptionText("```\nfoo  up\nbar  down\n```", false),
+				slack.MsgOptionAttachments(slack.Attachment{Title: "status"}),
+			}
+		})),
+	)
+
+	tests := []struct {
+		input string
+		want  url.Values
+	}{
+		{input: "logs | grep error | tail 1 | upper", want: url.Values{"text": {"3 ERROR"}}},
+		{input: "logs | wc", want: url.Values{"text": {"4"}}},
+		{input: "status | grep up", want: url.Values{"text": {"```\nfoo  up\n```"}, "attachments": {`[{"title":"status","blocks":null}]`}}},
+		{input: "logs | foo", want: url.Values{"attachments": {`[{"color":"bad","title":"invalid filter","text":"supported filters: grep, head, sort, tail, uniq, upper, wc","blocks":null}]`}}},
+		{input: "logs | head x", want: url.Values{"attachments": {`[{"color":"bad","title":"invalid filter","text":"head: usage: [n]","blocks":null}]`}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1"}, tt.input))
+			post := <-ts.post
+			for k := range tt.want {
+				assert.Equal(t, tt.want.Get(k), post.Get(k), k)
+			}
+		})
+	}
+}
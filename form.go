@@ -0,0 +1,270 @@
+package slackapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/slack-go/slack"
+	"slices"
+	"strings"
+)
+
+const (
+	formActionID   = "slackapp_form_open"
+	formCallbackID = "slackapp_form"
+)
+
+var _ Handler = Form{}
+
+// A Form is a Handler for commands with many arguments. Invoking the command with arguments executes the Handler directly.
+// Invoking the command without arguments (or through a slash command or shortcut) lets the user enter the arguments
+// in a modal form. When the user submits the form, the Bot validates the fields and calls the Handler with the entered
+// values, in the order of Arguments. Optional arguments that were left empty are passed as empty strings.
+//
+// Slack only allows a modal to be opened in response to an interaction. When the command is invoked by mentioning the bot,
+// the Form therefore replies with a button that opens the modal. To open the form from a global or message shortcut,
+// use the command (e.g. "deploy" or "cluster deploy") as the shortcut's callback ID.
+type Form struct {
+	Handler   Handler
+	Title     string
+	Arguments []Argument
+}
+
+// An Argument describes one field of a Form.
+type Argument struct {
+	// Name identifies the argument.
+	Name string
+	// Label is shown above the field. If empty, Name is used.
+	Label string
+	// Placeholder is shown in the empty field.
+	Placeholder string
+	// Optional arguments may be left empty.
+	Optional bool
+	// Multiline shows a multi-line text field.
+	Multiline bool
+	// Options, if set, shows a drop-down list with the provided values, instead of a text field.
+	Options []string
+	// Validate, if set, validates the entered value. The returned error is shown next to the field.
+	Validate func(string) error
+	// Sensitive arguments (e.g. passwords) are redacted in the Bot's logs, history and audit log.
+	Sensitive bool
+}
+
+// Handle executes the Handler if arguments are provided. Otherwise, it returns a message with a button to open the form.
+func (f Form) Handle(ctx context.Context, args ...string) []slack.MsgOption {
+	if len(args) > 0 {
+		return f.Handler.Handle(ctx, args...)
+	}
+	path := strings.Join(CommandPath(ctx), " ")
+	button := slack.NewButtonBlockElement(formActionID, path, slack.NewTextBlockObject(slack.PlainTextType, Translate(ctx, "Open form"), false, false))
+	return []slack.MsgOption{slack.MsgOptionBlocks(
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, Translate(ctx, "Enter the arguments for `%s`", path), false, false), nil, nil),
+		slack.NewActionBlock("", button),
+	)}
+}
+
+type formMetadata struct {
+	Path    []string `json:"path"`
+	Channel string   `json:"channel,omitempty"`
+}
+
+func (f Form) modal(ctx context.Context, metadata formMetadata) slack.ModalViewRequest {
+	title := f.Title
+	if title == "" {
+		title = strings.Join(metadata.Path, " ")
+	}
+	blocks := make([]slack.Block, len(f.Arguments))
+	for i, arg := range f.Arguments {
+		blocks[i] = arg.block()
+	}
+	encodedMetadata, _ := json.Marshal(metadata)
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      formCallbackID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, truncate(title, 24), false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, Translate(ctx, "Run"), false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, Translate(ctx, "Cancel"), false, false),
+		Blocks:          slack.Blocks{BlockSet: blocks},
+		PrivateMetadata: string(encodedMetadata),
+	}
+}
+
+func (a Argument) block() slack.Block {
+	label := a.Label
+	if label == "" {
+		label = a.Name
+	}
+	var placeholder *slack.TextBlockObject
+	if a.Placeholder != "" {
+		placeholder = slack.NewTextBlockObject(slack.PlainTextType, a.Placeholder, false, false)
+	}
+	var element slack.BlockElement
+	if len(a.Options) > 0 {
+		options := make([]*slack.OptionBlockObject, len(a.Options))
+		for i, option := range a.Options {
+			options[i] = slack.NewOptionBlockObject(option, slack.NewTextBlockObject(slack.PlainTextType, option, false, false), nil)
+		}
+		element = slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, placeholder, a.Name, options...)
+	} else {
+		input := slack.NewPlainTextInputBlockElement(placeholder, a.Name)
+		input.Multiline = a.Multiline
+		element = input
+	}
+	block := slack.NewInputBlock(a.Name, slack.NewTextBlockObject(slack.PlainTextType, label, false, false), nil, element)
+	block.Optional = a.Optional
+	return block
+}
+
+// values returns the submitted values, in the order of the Form's Arguments. If any values are invalid, it returns
+// the validation errors, keyed by argument name.
+func (f Form) values(ctx context.Context, state *slack.ViewState) ([]string, map[string]string) {
+	values := make([]string, len(f.Arguments))
+	errs := make(map[string]string)
+	for i, arg := range f.Arguments {
+		var value string
+		if state != nil {
+			action := state.Values[arg.Name][arg.Name]
+			value = action.Value
+			if action.SelectedOption.Value != "" {
+				value = action.SelectedOption.Value
+			}
+		}
+		values[i] = value
+		if value == "" {
+			if !arg.Optional {
+				errs[arg.Name] = Translate(ctx, "required")
+			}
+			continue
+		}
+		if arg.Validate != nil {
+			if err := arg.Validate(value); err != nil {
+				errs[arg.Name] = err.Error()
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return values, nil
+}
+
+func truncate(text string, size int) string {
+	if runes := []rune(text); len(runes) > size {
+		return string(runes[:size-1]) + "…"
+	}
+	return text
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lookupForm returns the Form registered for the command, and the command's path.
+func (b *Bot) lookupForm(ctx context.Context, args ...string) (Form, []string, bool) {
+	handler, path, args := b.Commands.resolve(withMatching(ctx, b.matching), args...)
+	if len(args) > 0 {
+		return Form{}, nil, false
+	}
+	switch form := handler.(type) {
+	case Form:
+		return form, path, true
+	case *Form:
+		return *form, path, true
+	default:
+		return Form{}, nil, false
+	}
+}
+
+func (b *Bot) openForm(ctx context.Context, teamID string, userID string, triggerID string, metadata formMetadata) error {
+	form, _, ok := b.lookupForm(ctx, metadata.Path...)
+	if !ok {
+		return fmt.Errorf("no form for command %q", strings.Join(metadata.Path, " "))
+	}
+	client, err := b.client(ctx, teamID)
+	if err == nil {
+		_, err = client.OpenViewContext(ctx, triggerID, form.modal(b.withLocale(ctx, teamID, userID), metadata))
+	}
+	return err
+}
+
+// submitForm validates the submitted form. If the form is valid, it executes the command and posts the output to the
+// channel where the form was requested (or to the user, if the form wasn't opened from a channel). Otherwise, it returns
+// the view submission response with the validation errors.
+func (b *Bot) submitForm(ctx context.Context, callback slack.InteractionCallback) any {
+	var metadata formMetadata
+	if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &metadata); err != nil {
+		b.logger.Warn("invalid form metadata", "err", err)
+		return nil
+	}
+	form, _, ok := b.lookupForm(ctx, metadata.Path...)
+	if !ok {
+		b.logger.Warn("form submitted for unknown command", "cmd", strings.Join(metadata.Path, " "))
+		return nil
+	}
+	values, errs := form.values(b.withLocale(ctx, callback.Team.ID, callback.User.ID), callback.View.State)
+	if errs != nil {
+		return slack.NewErrorsViewSubmissionResponse(errs)
+	}
+	channel := metadata.Channel
+	if channel == "" {
+		channel = callback.User.ID
+	}
+	b.background(func(ctx context.Context) {
+		formCtx := ctx
+		for _, verb := range metadata.Path {
+			formCtx = withCommandPath(formCtx, verb)
+		}
+		req := Request{TeamID: callback.Team.ID, ChannelID: channel, UserID: callback.User.ID}
+		args := append(slices.Clone(metadata.Path), values...)
+		b.record(formCtx, req, args)
+		if err := b.run(formCtx, req, b.audited(nil, args, func(ctx context.Context) []slack.MsgOption { return form.Handler.Handle(ctx, values...) })); err != nil {
+			b.logger.Warn("failed to post form output", "channel", channel, "err", err)
+		}
+	})
+	return nil
+}
+
+// onInteraction processes interactive events: opening a Form from its button or from a shortcut, submitting a Form, and
+// clicking a Button bound to an action (see WithAction).
+func (b *Bot) onInteraction(ctx context.Context, callback slack.InteractionCallback) any {
+	switch callback.Type {
+	case slack.InteractionTypeBlockActions:
+		for _, action := range callback.ActionCallback.BlockActions {
+			if action.ActionID != formActionID {
+				b.onAction(ctx, callback, action)
+				continue
+			}
+			metadata := formMetadata{Path: strings.Fields(action.Value), Channel: callback.Channel.ID}
+			if err := b.openForm(ctx, callback.Team.ID, callback.User.ID, callback.TriggerID, metadata); err != nil {
+				b.logger.Warn("failed to open form", "err", err)
+			}
+		}
+	case slack.InteractionTypeShortcut, slack.InteractionTypeMessageAction:
+		metadata := formMetadata{Path: strings.Fields(callback.CallbackID), Channel: callback.Channel.ID}
+		if err := b.openForm(ctx, callback.Team.ID, callback.User.ID, callback.TriggerID, metadata); err != nil {
+			b.logger.Warn("failed to open form", "err", err)
+		}
+	case slack.InteractionTypeViewSubmission:
+		if callback.View.CallbackID == formCallbackID {
+			return b.submitForm(ctx, callback)
+		}
+	}
+	return nil
+}
+
+// onSlashCommand executes the slash command's text as a command. If the command is a Form and no arguments are
+// provided, it opens the form instead.
+func (b *Bot) onSlashCommand(ctx context.Context, cmd slack.SlashCommand) any {
+	args := tokenizeText(cmd.Text)
+	if _, path, ok := b.lookupForm(ctx, args...); ok && len(args) > 0 {
+		if err := b.openForm(ctx, cmd.TeamID, cmd.UserID, cmd.TriggerID, formMetadata{Path: path, Channel: cmd.ChannelID}); err != nil {
+			b.logger.Warn("failed to open form", "err", err)
+		}
+		return nil
+	}
+	b.background(func(ctx context.Context) {
+		req := Request{TeamID: cmd.TeamID, ChannelID: cmd.ChannelID, UserID: cmd.UserID}
+		if err := b.handle(ctx, req, cmd.Text); err != nil {
+			b.logger.Warn("failed to post command output", "channel", cmd.ChannelID, "err", err)
+		}
+	})
+	return nil
+}
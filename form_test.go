@@ -0,0 +1,187 @@
+package slackapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/clambin/slackapp/internal/testutils"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func testForm() Form {
+	return Form{
+		Handler: HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("deploy: "+strings.Join(args, ", "), false)}
+		}),
+		Arguments: []Argument{
+			{Name: "service", Label: "Service"},
+			{Name: "env", Options: []string{"dev", "prod"}},
+			{Name: "replicas", Optional: true, Validate: func(s string) error {
+				if s != "1" && s != "2" {
+					return errors.New("must be 1 or 2")
+				}
+				return nil
+			}},
+		},
+	}
+}
+
+func TestForm_Handle(t *testing.T) {
+	c := Commands{"cluster": Commands{"deploy": testForm()}}
+
+	output := formatMessage(c.Handle(context.Background(), "cluster", "deploy", "api", "prod"))
+	assert.Equal(t, "deploy: api, prod", output.Get("text"))
+
+	output = formatMessage(c.Handle(context.Background(), "cluster", "deploy"))
+	assert.Contains(t, output.Get("blocks"), `"action_id":"`+formActionID+`"`)
+	assert.Contains(t, output.Get("blocks"), `"value":"cluster deploy"`)
+	assert.Contains(t, output.Get("blocks"), `"text":"Open form"`)
+
+	// the form's prompt and button are translated
+	ctx := withLocalizer(context.Background(), Catalog{"fr": {
+		"Open form":                    "Ouvrir le formulaire",
+		"Enter the arguments for `%s`": "Saisissez les arguments de `%s`",
+	}}, "fr")
+	output = formatMessage(c.Handle(ctx, "cluster", "deploy"))
+	assert.Contains(t, output.Get("blocks"), `"text":"Ouvrir le formulaire"`)
+	assert.Contains(t, output.Get("blocks"), "Saisissez les arguments de `cluster deploy`")
+}
+
+func TestForm_values(t *testing.T) {
+	state := func(service, env, replicas string) *slack.ViewState {
+		return &slack.ViewState{Values: map[string]map[string]slack.BlockAction{
+			"service":  {"service": {Value: service}},
+			"env":      {"env": {SelectedOption: slack.OptionBlockObject{Value: env}}},
+			"replicas": {"replicas": {Value: replicas}},
+		}}
+	}
+	tests := []struct {
+		name       string
+		state      *slack.ViewState
+		wantValues []string
+		wantErrs   map[string]string
+	}{
+		{
+			name:       "valid",
+			state:      state("api", "prod", "2"),
+			wantValues: []string{"api", "prod", "2"},
+		},
+		{
+			name:       "optional",
+			state:      state("api", "prod", ""),
+			wantValues: []string{"api", "prod", ""},
+		},
+		{
+			name:     "invalid",
+			state:    state("", "prod", "3"),
+			wantErrs: map[string]string{"service": "required", "replicas": "must be 1 or 2"},
+		},
+		{
+			name:     "empty",
+			wantErrs: map[string]string{"service": "required", "env": "required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, errs := testForm().values(context.Background(), tt.state)
+			assert.Equal(t, tt.wantValues, values)
+			assert.Equal(t, tt.wantErrs, errs)
+		})
+	}
+}
+
+func TestForm_modal(t *testing.T) {
+	modal := testForm().modal(context.Background(), formMetadata{Path: []string{"cluster", "deploy"}, Channel: "C1"})
+	assert.Equal(t, formCallbackID, modal.CallbackID)
+	assert.Equal(t, "cluster deploy", modal.Title.Text)
+	assert.Equal(t, `{"path":["cluster","deploy"],"channel":"C1"}`, modal.PrivateMetadata)
+	require.Len(t, modal.Blocks.BlockSet, 3)
+	assert.Equal(t, "Service", modal.Blocks.BlockSet[0].(*slack.InputBlock).Label.Text)
+	assert.IsType(t, &slack.SelectBlockElement{}, modal.Blocks.BlockSet[1].(*slack.InputBlock).Element)
+	assert.True(t, modal.Blocks.BlockSet[2].(*slack.InputBlock).Optional)
+}
+
+func TestBot_Form(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values), views: make(chan []byte)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	api := slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/"))
+	var h testutils.FakeHandler
+	b := newBotWith(api, &h,
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("deploy", testForm()),
+	)
+
+	errCh := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { errCh <- b.Run(ctx) }()
+
+	slackClient := slack.New("", slack.OptionHTTPClient(&http.Client{Transport: &testutils.StubbedRoundTripper{}}))
+	smClient := socketmode.New(slackClient)
+
+	// the button opens the form
+	go h.SendInteraction(testutils.InteractionEvent(slack.InteractionCallback{
+		Type:      slack.InteractionTypeBlockActions,
+		TriggerID: "1",
+		Channel:   slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}},
+		ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+			{ActionID: formActionID, Value: "deploy"},
+		}},
+	}), smClient)
+
+	var request struct {
+		TriggerID string                 `json:"trigger_id"`
+		View      slack.ModalViewRequest `json:"view"`
+	}
+	require.NoError(t, json.Unmarshal(<-ts.views, &request))
+	assert.Equal(t, "1", request.TriggerID)
+	assert.Equal(t, `{"path":["deploy"],"channel":"C1"}`, request.View.PrivateMetadata)
+
+	// an invalid submission returns the validation errors
+	submission := slack.InteractionCallback{
+		Type: slack.InteractionTypeViewSubmission,
+		User: slack.User{ID: "U1"},
+		View: slack.View{
+			CallbackID:      formCallbackID,
+			PrivateMetadata: request.View.PrivateMetadata,
+			State: &slack.ViewState{Values: map[string]map[string]slack.BlockAction{
+				"service": {"service": {Value: "api"}},
+			}},
+		},
+	}
+	resp := b.onInteraction(ctx, submission)
+	assert.Equal(t, slack.NewErrorsViewSubmissionResponse(map[string]string{"env": "required"}), resp)
+
+	// a valid submission executes the command
+	submission.View.State.Values["env"] = map[string]slack.BlockAction{"env": {SelectedOption: slack.OptionBlockObject{Value: "dev"}}}
+	go h.SendInteraction(testutils.InteractionEvent(submission), smClient)
+	post := <-ts.post
+	assert.Equal(t, "C1", post.Get("channel"))
+	assert.Equal(t, "deploy: api, dev, ", post.Get("text"))
+
+	// a slash command without arguments opens the form
+	go h.SendSlashCommand(testutils.SlashCommandEvent(slack.SlashCommand{Command: "/bot", Text: "deploy", TriggerID: "2", ChannelID: "C2"}), smClient)
+	require.NoError(t, json.Unmarshal(<-ts.views, &request))
+	assert.Equal(t, "2", request.TriggerID)
+
+	// a slash command with arguments executes the command
+	go h.SendSlashCommand(testutils.SlashCommandEvent(slack.SlashCommand{Command: "/bot", Text: "deploy api prod", ChannelID: "C2"}), smClient)
+	post = <-ts.post
+	assert.Equal(t, "C2", post.Get("channel"))
+	assert.Equal(t, "deploy: api, prod", post.Get("text"))
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
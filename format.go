@@ -0,0 +1,251 @@
+package slackapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/slack-go/slack"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// A Format determines how a ResultHandlerFunc's result is rendered.
+type Format string
+
+const (
+	// FormatTable renders the result as a table in a code block. This is the default.
+	FormatTable Format = "table"
+	// FormatJSON renders the result as JSON in a code block.
+	FormatJSON Format = "json"
+	// FormatCSV uploads the result as a CSV file.
+	FormatCSV Format = "csv"
+)
+
+var formats = []Format{FormatTable, FormatJSON, FormatCSV}
+
+var _ Handler = ResultHandlerFunc(nil)
+
+// ResultHandlerFunc is an adapter that allows a function returning a structured result to be used as a Handler.
+// A result is a struct or a map with string keys, or a slice of these. Users select how the result is rendered with
+// the --format argument, e.g. "status --format=json". See Format for the supported formats.
+//
+// If the function returns an error, the command fails and the Bot replies with the error.
+type ResultHandlerFunc func(context.Context, ...string) (any, error)
+
+// Handle calls f(ctx, args), without the --format argument, and renders the result.
+func (f ResultHandlerFunc) Handle(ctx context.Context, args ...string) []slack.MsgOption {
+	format, args, ok := parseFormat(args...)
+	if !ok {
+		return errorMessage(ctx, "invalid format", Translate(ctx, "supported formats: %s", strings.Join(formatNames(), ", ")))
+	}
+	result, err := f(ctx, args...)
+	if err != nil {
+		return errorMessage(ctx, "command failed", err.Error())
+	}
+	output, err := render(ctx, format, result)
+	if err != nil {
+		return errorMessage(ctx, "command failed", err.Error())
+	}
+	return output
+}
+
+// parseFormat removes the --format argument (either "--format=json" or "--format json") from the arguments.
+func parseFormat(args ...string) (Format, []string, bool) {
+	format := FormatTable
+	remaining := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		value, ok := strings.CutPrefix(args[i], "--format=")
+		if !ok && args[i] == "--format" && i+1 < len(args) {
+			value, ok = args[i+1], true
+			i++
+		}
+		if !ok {
+			remaining = append(remaining, args[i])
+			continue
+		}
+		if format = Format(strings.ToLower(value)); !slices.Contains(formats, format) {
+			return "", nil, false
+		}
+	}
+	return format, remaining, true
+}
+
+func formatNames() []string {
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = string(format)
+	}
+	return names
+}
+
+func render(ctx context.Context, format Format, result any) ([]slack.MsgOption, error) {
+	switch format {
+	case FormatJSON:
+		body, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return []slack.MsgOption{slack.MsgOptionText(codeBlock(string(body)), false)}, nil
+	case FormatCSV:
+		var body bytes.Buffer
+		w := csv.NewWriter(&body)
+		header, rows := tabulate(result)
+		_ = w.Write(header)
+		_ = w.WriteAll(rows)
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+		name := strings.Join(append(CommandPath(ctx), "output"), "-") + ".csv"
+		if Respond(ctx, Public().WithFiles(File{Name: name, Type: "text/csv", Content: body.Bytes()})) {
+			return nil, nil
+		}
+		// not running in a Bot: we can't upload the file
+		return []slack.MsgOption{slack.MsgOptionText(codeBlock(strings.TrimSuffix(body.String(), "\n")), false)}, nil
+	default:
+		header, rows := tabulate(result)
+		if len(rows) == 0 {
+			return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "no results"), false)}, nil
+		}
+		return []slack.MsgOption{slack.MsgOptionText(codeBlock(formatTable(header, rows)), false)}, nil
+	}
+}
+
+// formatTable aligns the columns of the header and rows.
+func formatTable(header []string, rows [][]string) string {
+	var body bytes.Buffer
+	w := tabwriter.NewWriter(&body, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	_ = w.Flush()
+	lines := strings.Split(strings.TrimRight(body.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func codeBlock(text string) string {
+	return "```\n" + text + "\n```"
+}
+
+// codeBlockContent returns the content of the code block. If the text isn't a code block, it returns the text and false.
+func codeBlockContent(text string) (string, bool) {
+	content, ok := strings.CutPrefix(text, "```")
+	if !ok || !strings.HasSuffix(content, "```") {
+		return text, false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSuffix(content, "```"), "\n"), "\n"), true
+}
+
+// tabulate returns the header and rows of the result. Each struct or map is a row: the header holds the names of the
+// (exported) fields of the struct, or the keys of the map. Other values are returned as a single "value" column.
+func tabulate(result any) ([]string, [][]string) {
+	v := reflect.ValueOf(result)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return []string{"value"}, nil
+		}
+		v = v.Elem()
+	}
+	items := []reflect.Value{v}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		items = make([]reflect.Value, v.Len())
+		for i := range items {
+			items[i] = reflect.Indirect(v.Index(i))
+			if items[i].Kind() == reflect.Interface && !items[i].IsNil() {
+				items[i] = reflect.Indirect(items[i].Elem())
+			}
+		}
+	}
+
+	var header []string
+	for _, item := range items {
+		for _, column := range columns(item) {
+			if !slices.Contains(header, column) {
+				header = append(header, column)
+			}
+		}
+	}
+	if len(header) == 0 {
+		header = []string{"value"}
+	}
+
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = make([]string, len(header))
+		for j, column := range header {
+			rows[i][j] = cell(item, column)
+		}
+	}
+	return header, rows
+}
+
+// columns returns the names of the struct's exported fields (using the name in its json tag, if present), or the sorted
+// keys of the map. For other values, it returns nil.
+func columns(v reflect.Value) []string {
+	var names []string
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			if name, ok := fieldName(field); ok {
+				names = append(names, name)
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			for _, key := range v.MapKeys() {
+				names = append(names, key.String())
+			}
+			slices.Sort(names)
+		}
+	}
+	return names
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() || field.Anonymous {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return name, true
+	}
+}
+
+// cell returns the value of the struct field or map key in column, or the value itself for the "value" column.
+func cell(v reflect.Value, column string) string {
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			if name, ok := fieldName(field); ok && name == column {
+				if value, err := v.FieldByIndexErr(field.Index); err == nil {
+					return fmt.Sprint(value.Interface())
+				}
+				return ""
+			}
+		}
+		return ""
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			if value := v.MapIndex(reflect.ValueOf(column).Convert(v.Type().Key())); value.IsValid() {
+				return fmt.Sprint(value.Interface())
+			}
+			return ""
+		}
+	}
+	if column == "value" && v.IsValid() {
+		return fmt.Sprint(v.Interface())
+	}
+	return ""
+}
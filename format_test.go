@@ -0,0 +1,107 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type service struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Uptime  int
+	private string
+	Ignored string `json:"-"`
+}
+
+func TestResultHandlerFunc(t *testing.T) {
+	services := []service{{Name: "api", Status: "up", Uptime: 42, private: "x"}, {Name: "database", Status: "down"}}
+	tests := []struct {
+		name   string
+		result any
+		err    error
+		args   []string
+		want   map[string]string
+	}{
+		{
+			name:   "table",
+			result: services,
+			want:   map[string]string{"text": "```\nname      status  Uptime\napi       up      42\ndatabase  down    0\n```"},
+		},
+		{
+			name:   "json",
+			result: services[:1],
+			args:   []string{"--format=JSON"},
+			want:   map[string]string{"text": "```\n[\n  {\n    \"name\": \"api\",\n    \"status\": \"up\",\n    \"Uptime\": 42\n  }\n]\n```"},
+		},
+		{
+			name:   "csv",
+			result: &services[1],
+			args:   []string{"--format", "csv"},
+			want:   map[string]string{"text": "```\nname,status,Uptime\ndatabase,down,0\n```"},
+		},
+		{
+			name:   "maps",
+			result: []any{map[string]int{"b": 1, "a": 2}, map[string]int{"c": 3}},
+			want:   map[string]string{"text": "```\na  b  c\n2  1\n      3\n```"},
+		},
+		{
+			name:   "values",
+			result: []string{"foo", "bar"},
+			want:   map[string]string{"text": "```\nvalue\nfoo\nbar\n```"},
+		},
+		{
+			name:   "empty",
+			result: []service{},
+			want:   map[string]string{"text": "no results"},
+		},
+		{
+			name: "invalid format",
+			args: []string{"--format=xml"},
+			want: map[string]string{"attachments": `[{"color":"bad","title":"invalid format","text":"supported formats: table, json, csv","blocks":null}]`},
+		},
+		{
+			name: "error",
+			err:  errors.New("failed"),
+			want: map[string]string{"attachments": `[{"color":"bad","title":"command failed","text":"failed","blocks":null}]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := ResultHandlerFunc(func(_ context.Context, args ...string) (any, error) {
+				assert.Empty(t, args)
+				return tt.result, tt.err
+			})
+			output := formatMessage(h.Handle(context.Background(), tt.args...))
+			for k, v := range tt.want {
+				assert.Equal(t, v, output.Get(k), k)
+			}
+		})
+	}
+}
+
+func TestBot_ResultHandler_Upload(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), uploads: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("status", ResultHandlerFunc(func(_ context.Context, _ ...string) (any, error) {
+			return []service{{Name: "api", Status: "up"}}, nil
+		})),
+	)
+	require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1"}, "status --format=csv"))
+	upload := <-ts.uploads
+	assert.Equal(t, "C1", upload.Get("channel"))
+	assert.Equal(t, "status-output.csv:name,status,Uptime\napi,up,0\n", upload.Get("file"))
+	assert.Empty(t, ts.post)
+}
@@ -0,0 +1,128 @@
+package slackapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/slack-go/slack"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A HomeRenderer builds the App Home view for a user. The Bot calls it whenever a user opens the bot's App Home tab
+// and publishes the resulting view for that user.
+type HomeRenderer interface {
+	RenderHome(ctx context.Context, userID string) slack.HomeTabViewRequest
+}
+
+// HomeRendererFunc is an adapter that allows a function to be used as a HomeRenderer
+type HomeRendererFunc func(ctx context.Context, userID string) slack.HomeTabViewRequest
+
+// RenderHome calls f(ctx, userID)
+func (f HomeRendererFunc) RenderHome(ctx context.Context, userID string) slack.HomeTabViewRequest {
+	return f(ctx, userID)
+}
+
+var _ HomeRenderer = &Bot{}
+
+// RenderHome renders the Bot's default App Home view: the connection status, the supported commands and the
+// user's recently executed commands.
+func (b *Bot) RenderHome(ctx context.Context, userID string) slack.HomeTabViewRequest {
+	status := ":red_circle: " + Translate(ctx, "disconnected")
+	if b.SlackApp != nil && b.SlackApp.Connected() {
+		status = ":large_green_circle: " + Translate(ctx, "connected")
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, Translate(ctx, "Status"), false, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, status, false, false), nil, nil),
+		slack.NewDividerBlock(),
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, Translate(ctx, "Commands"), false, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, markdownList(b.GetCommands()), false, false), nil, nil),
+	}
+
+	if history := b.History(userID); len(history) > 0 {
+		entries := make([]string, len(history))
+		for i, entry := range history {
+			entries[i] = fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s> in <#%s>: %s",
+				entry.Timestamp.Unix(), entry.Timestamp.Format(time.RFC3339), entry.Channel, entry.Command,
+			)
+		}
+		blocks = append(blocks,
+			slack.NewDividerBlock(),
+			slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, Translate(ctx, "Recent commands"), false, false)),
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, markdownList(entries), false, false), nil, nil),
+		)
+	}
+
+	return slack.HomeTabViewRequest{Type: slack.VTHomeTab, Blocks: slack.Blocks{BlockSet: blocks}}
+}
+
+func markdownList(items []string) string {
+	if len(items) == 0 {
+		return "_none_"
+	}
+	return "• " + strings.Join(items, "\n• ")
+}
+
+func (b *Bot) publishHome(ctx context.Context, teamID string, userID string) error {
+	if b.home == nil {
+		return nil
+	}
+	client, err := b.client(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	b.logger.Debug("publishing app home", "user", userID)
+	_, err = client.PublishViewContext(ctx, userID, b.home.RenderHome(withRequest(b.withLocale(ctx, teamID, userID), Request{TeamID: teamID, UserID: userID}), userID), "")
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// A HistoryEntry records a command executed by a user.
+type HistoryEntry struct {
+	Timestamp time.Time
+	Channel   string
+	Command   string
+}
+
+// defaultHistorySize is the number of commands remembered for each user.
+const defaultHistorySize = 10
+
+type history struct {
+	entries map[string][]HistoryEntry
+	size    int
+	lock    sync.RWMutex
+}
+
+func (h *history) add(userID string, entry HistoryEntry) {
+	if userID == "" {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.entries == nil {
+		h.entries = make(map[string][]HistoryEntry)
+	}
+	entries := append(h.entries[userID], entry)
+	if len(entries) > h.size {
+		entries = entries[len(entries)-h.size:]
+	}
+	h.entries[userID] = entries
+}
+
+// get returns the user's history, most recent command first.
+func (h *history) get(userID string) []HistoryEntry {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	entries := slices.Clone(h.entries[userID])
+	slices.Reverse(entries)
+	return entries
+}
+
+// History returns the most recent commands executed by the user, most recent command first.
+func (b *Bot) History(userID string) []HistoryEntry {
+	return b.history.get(userID)
+}
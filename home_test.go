@@ -0,0 +1,79 @@
+package slackapp
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/clambin/slackapp/internal/testutils"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBot_Home(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values), views: make(chan []byte)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	api := slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/"))
+	var h testutils.FakeHandler
+	b := newBotWith(api, &h,
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithDefaultHome(),
+		WithCommand("foo", HandlerFunc(func(ctx context.Context, s ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("foo", false)}
+		})),
+	)
+
+	errCh := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { errCh <- b.Run(ctx) }()
+
+	slackClient := slack.New("", slack.OptionHTTPClient(&http.Client{Transport: &testutils.StubbedRoundTripper{}}))
+	smClient := socketmode.New(slackClient)
+
+	// run a command, so it shows up in the user's history
+	ev := testutils.AppMentionEvent("<@W23456789> foo bar")
+	ev.Data.(slackevents.EventsAPIEvent).InnerEvent.Data.(*slackevents.AppMentionEvent).User = "U1"
+	go h.SendEvent(ev, smClient)
+	<-ts.post
+
+	go h.SendEvent(testutils.AppHomeOpenedEvent("U1"), smClient)
+	var request struct {
+		UserID string                   `json:"user_id"`
+		View   slack.HomeTabViewRequest `json:"view"`
+	}
+	require.NoError(t, json.Unmarshal(<-ts.views, &request))
+	assert.Equal(t, "U1", request.UserID)
+	assert.Equal(t, slack.VTHomeTab, request.View.Type)
+	require.Len(t, request.View.Blocks.BlockSet, 8)
+	assert.Contains(t, request.View.Blocks.BlockSet[4].(*slack.SectionBlock).Text.Text, "foo")
+	assert.Contains(t, request.View.Blocks.BlockSet[7].(*slack.SectionBlock).Text.Text, "foo bar")
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func Test_history(t *testing.T) {
+	h := history{size: 2}
+	assert.Empty(t, h.get("U1"))
+	for i := range 3 {
+		h.add("U1", HistoryEntry{Timestamp: time.Now(), Command: strconv.Itoa(i)})
+	}
+	h.add("", HistoryEntry{Command: "ignored"})
+
+	entries := h.get("U1")
+	require.Len(t, entries, 2)
+	assert.Equal(t, "2", entries[0].Command)
+	assert.Equal(t, "1", entries[1].Command)
+	assert.Empty(t, h.get(""))
+}
@@ -0,0 +1,116 @@
+package slackapp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// A Resolver maps free text to a command. When a message doesn't start with a known command, the Bot passes its
+// arguments to the Resolver (see WithFallback) before replying that the command is invalid. The Resolver returns the
+// command to execute (its path, followed by its arguments), or false if it can't map the text to a command.
+//
+// Intents provides a Resolver based on keywords and regular expressions. Implement Resolver to use e.g. an external
+// classifier instead.
+type Resolver interface {
+	Resolve(ctx context.Context, args ...string) ([]string, bool)
+}
+
+// ResolverFunc is an adapter that allows a function to be used as a Resolver
+type ResolverFunc func(ctx context.Context, args ...string) ([]string, bool)
+
+// Resolve calls f(ctx, args)
+func (f ResolverFunc) Resolve(ctx context.Context, args ...string) ([]string, bool) {
+	return f(ctx, args...)
+}
+
+// An Intent maps text matching its Pattern to a Command.
+type Intent struct {
+	Pattern *regexp.Regexp
+	// Command holds the command's path and arguments, e.g. ["deploy", "$service"]. References to the Pattern's
+	// capturing groups ($1, $name or ${name}) are replaced by the text they matched. Arguments that are empty after
+	// replacement (i.e. the group didn't match) are dropped.
+	Command []string
+}
+
+// Keyword returns an Intent that maps any text containing the keyword (as a whole word, regardless of case) to the command.
+func Keyword(keyword string, command ...string) Intent {
+	return Intent{Pattern: regexp.MustCompile(`(?i)(?:^|\W)` + regexp.QuoteMeta(keyword) + `(?:\W|$)`), Command: command}
+}
+
+var _ Resolver = Intents{}
+
+// Intents is a Resolver that maps text to the Command of the first Intent whose Pattern matches the text, e.g.:
+//
+//	slackapp.Intents{
+//		{Pattern: regexp.MustCompile(`(?i)^(?:please )?deploy (?P<service>\w+)(?: to (?P<env>\w+))?`), Command: []string{"deploy", "$service", "$env"}},
+//		slackapp.Keyword("status", "status"),
+//	}
+//
+// maps "please deploy api to prod" to "deploy api prod", and "what's the status?" to "status".
+type Intents []Intent
+
+// Resolve matches the arguments, joined by spaces, against the Intents' patterns.
+func (i Intents) Resolve(_ context.Context, args ...string) ([]string, bool) {
+	text := strings.Join(args, " ")
+	for _, intent := range i {
+		match := intent.Pattern.FindStringSubmatchIndex(text)
+		if match == nil {
+			continue
+		}
+		command := make([]string, 0, len(intent.Command))
+		for _, template := range intent.Command {
+			if arg := string(intent.Pattern.ExpandString(nil, template, text, match)); arg != "" {
+				command = append(command, arg)
+			}
+		}
+		return command, len(command) > 0
+	}
+	return nil, false
+}
+
+type resolverKey struct{}
+
+// withResolver records the Resolver Commands should use for text that doesn't match a command.
+func withResolver(ctx context.Context, resolver Resolver) context.Context {
+	return context.WithValue(ctx, resolverKey{}, resolver)
+}
+
+// fallback maps the arguments to a known command, using the Resolver in the context. Only top-level Commands use the
+// Resolver and the resolved command itself isn't resolved again.
+func (c Commands) fallback(ctx context.Context, args ...string) (context.Context, []string, bool) {
+	resolver, ok := ctx.Value(resolverKey{}).(Resolver)
+	if !ok || resolver == nil || len(args) == 0 || len(CommandPath(ctx)) > 0 {
+		return ctx, nil, false
+	}
+	resolved, ok := resolver.Resolve(ctx, args...)
+	if !ok || len(resolved) == 0 {
+		return ctx, nil, false
+	}
+	if _, _, ok = c.lookup(ctx, resolved[0]); !ok {
+		return ctx, nil, false
+	}
+	return withResolver(ctx, nil), resolved, true
+}
+
+// resolveFallback maps a command that doesn't start with a known command to the command returned by the Bot's
+// fallback Resolver (see WithFallback). Resolving the command before executing it lets the Bot record, audit and rate
+// limit the command that is actually executed. Other commands are returned as-is.
+func (b *Bot) resolveFallback(ctx context.Context, req Request, tokens []Token) []Token {
+	if b.fallback == nil || len(tokens) == 0 {
+		return tokens
+	}
+	ctx = b.commandContext(ctx, req)
+	if _, _, ok := b.Commands.lookup(ctx, tokens[0].Value); ok {
+		return tokens
+	}
+	_, resolved, ok := b.Commands.fallback(ctx, arguments(tokens)...)
+	if !ok {
+		return tokens
+	}
+	resolvedTokens := make([]Token, len(resolved))
+	for i, arg := range resolved {
+		resolvedTokens[i] = Token{Kind: TokenWord, Value: arg}
+	}
+	return resolvedTokens
+}
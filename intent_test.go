@@ -0,0 +1,105 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIntents_Resolve(t *testing.T) {
+	intents := Intents{
+		{Pattern: regexp.MustCompile(`(?i)^(?:please )?deploy (?P<service>\w+)(?: to (?P<env>\w+))?`), Command: []string{"deploy", "$service", "${env}"}},
+		Keyword("status", "status"),
+		Keyword("c++", "lang", "cpp"),
+	}
+	tests := []struct {
+		name   string
+		args   []string
+		want   []string
+		wantOK bool
+	}{
+		{name: "captures", args: []string{"please", "deploy", "api", "to", "prod"}, want: []string{"deploy", "api", "prod"}, wantOK: true},
+		{name: "optional capture", args: []string{"Deploy", "api"}, want: []string{"deploy", "api"}, wantOK: true},
+		{name: "keyword", args: []string{"what's", "the", "STATUS?"}, want: []string{"status"}, wantOK: true},
+		{name: "whole words only", args: []string{"statuses"}},
+		{name: "quoted keyword", args: []string{"c++"}, want: []string{"lang", "cpp"}, wantOK: true},
+		{name: "no match", args: []string{"hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := intents.Resolve(context.Background(), tt.args...)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommands_Fallback(t *testing.T) {
+	handler := HandlerFunc(func(ctx context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(append(CommandPath(ctx), args...), " "), true)}
+	})
+	c := Commands{
+		"deploy": handler,
+		"app":    Commands{"restart": handler},
+	}
+	resolver := ResolverFunc(func(_ context.Context, args ...string) ([]string, bool) {
+		switch strings.Join(args, " ") {
+		case "ship it":
+			return []string{"deploy", "api"}, true
+		case "bounce":
+			return []string{"app", "restart"}, true
+		case "loop":
+			return []string{"loop"}, true
+		case "unknown":
+			return []string{"foo"}, true
+		}
+		return nil, false
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{name: "resolved", args: []string{"ship", "it"}, want: map[string]string{"text": "deploy api"}},
+		{name: "resolved to nested command", args: []string{"bounce"}, want: map[string]string{"text": "app restart"}},
+		{name: "resolved to unknown command", args: []string{"unknown"}, want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"supported commands: app, deploy","blocks":null}]`}},
+		{name: "resolved to itself", args: []string{"loop"}, want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"supported commands: app, deploy","blocks":null}]`}},
+		{name: "nested commands don't resolve", args: []string{"app", "bounce"}, want: map[string]string{"attachments": `[{"color":"bad","title":"invalid command","text":"supported commands: restart","blocks":null}]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := formatMessage(c.Handle(withResolver(context.Background(), resolver), tt.args...))
+			for k, v := range tt.want {
+				require.Contains(t, output, k)
+				assert.Equal(t, v, output.Get(k))
+			}
+		})
+	}
+}
+
+func TestBot_Fallback(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithFallback(Intents{Keyword("status", "status")}),
+		WithCommand("status", HandlerFunc(func(_ context.Context, _ ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("all good", false)}
+		})),
+	)
+	require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1", UserID: "U1"}, "<@W23456789> what's the status?"))
+	assert.Equal(t, "all good", (<-ts.post).Get("text"))
+}
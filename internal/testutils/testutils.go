@@ -3,6 +3,7 @@ package testutils
 import (
 	"bytes"
 	"context"
+	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
 	"io"
@@ -26,7 +27,19 @@ func (f *FakeHandler) Handle(evt socketmode.EventType, h socketmode.SocketmodeHa
 }
 
 func (f *FakeHandler) SendEvent(ev *socketmode.Event, c *socketmode.Client) {
-	if h, ok := f.eventHandlers[socketmode.EventTypeEventsAPI]; ok {
+	f.send(socketmode.EventTypeEventsAPI, ev, c)
+}
+
+func (f *FakeHandler) SendInteraction(ev *socketmode.Event, c *socketmode.Client) {
+	f.send(socketmode.EventTypeInteractive, ev, c)
+}
+
+func (f *FakeHandler) SendSlashCommand(ev *socketmode.Event, c *socketmode.Client) {
+	f.send(socketmode.EventTypeSlashCommand, ev, c)
+}
+
+func (f *FakeHandler) send(evt socketmode.EventType, ev *socketmode.Event, c *socketmode.Client) {
+	if h, ok := f.eventHandlers[evt]; ok {
 		h(ev, c)
 	}
 }
@@ -60,3 +73,34 @@ func AppMentionEvent(text string) *socketmode.Event {
 		},
 	}
 }
+
+func AppHomeOpenedEvent(user string) *socketmode.Event {
+	return &socketmode.Event{
+		Request: &socketmode.Request{},
+		Data: slackevents.EventsAPIEvent{
+			InnerEvent: slackevents.EventsAPIInnerEvent{
+				Type: string(slackevents.AppHomeOpened),
+				Data: &slackevents.AppHomeOpenedEvent{
+					User: user,
+					Tab:  "home",
+				},
+			},
+		},
+	}
+}
+
+func InteractionEvent(callback slack.InteractionCallback) *socketmode.Event {
+	return &socketmode.Event{
+		Type:    socketmode.EventTypeInteractive,
+		Request: &socketmode.Request{},
+		Data:    callback,
+	}
+}
+
+func SlashCommandEvent(cmd slack.SlashCommand) *socketmode.Event {
+	return &socketmode.Event{
+		Type:    socketmode.EventTypeSlashCommand,
+		Request: &socketmode.Request{},
+		Data:    cmd,
+	}
+}
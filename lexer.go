@@ -0,0 +1,357 @@
+package slackapp
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// TokenKind identifies the type of Token.
+type TokenKind int
+
+const (
+	// TokenWord is a word or a quoted phrase.
+	TokenWord TokenKind = iota
+	// TokenCode is a code block (```code```) or inline code (`code`).
+	TokenCode
+	// TokenUser is a user mention, e.g. <@U12345>.
+	TokenUser
+	// TokenChannel is a channel mention, e.g. <#C12345|general>.
+	TokenChannel
+	// TokenSpecial is a special mention (e.g. <!here> or <!subteam^S12345>) or a formatted date.
+	TokenSpecial
+	// TokenLink is a URL, e.g. <https://example.com|example>.
+	TokenLink
+	// TokenEmail is an email address, e.g. <mailto:user@example.com|user@example.com>.
+	TokenEmail
+)
+
+// A Token is an argument of a command, as determined by Tokenize.
+type Token struct {
+	Kind TokenKind
+	// Value is the argument passed to a Handler: the (unquoted, unescaped) text for a word, the content of a code block,
+	// the URL for a link or the address for an email. Mentions are passed as-is (e.g. <@U12345>), so they can be
+	// included in a reply.
+	Value string
+	// Target is the ID of the mentioned user or channel, the special mention (e.g. "here"), the URL of a link or the
+	// address of an email.
+	Target string
+	// Label is the label of a mention or link, if provided by Slack.
+	Label string
+}
+
+// Tokenize splits the text of a message into tokens:
+//
+//   - words are separated by whitespace.
+//   - a phrase in quotes (straight or curly, double or single) is a single word. Quotes of one style may be used
+//     inside quotes of another. A single quote inside a word (e.g. don't) is a regular character.
+//   - a backslash escapes the next character, e.g. \" or \\.
+//   - a code block (```code```) or inline code (`code`) is a single token and is passed as-is.
+//   - Slack's markup is decoded: &amp;, &lt; and &gt; are replaced by &, < and >; <https://url|label> becomes a link,
+//     <mailto:address> an email, etc.
+//
+// Unterminated quotes are ignored.
+func Tokenize(input string) []Token {
+	l := lexer{input: []rune(input)}
+	var tokens []Token
+	for {
+		l.skipSpace()
+		if l.done() {
+			return tokens
+		}
+		if token, ok := l.token(); ok {
+			tokens = append(tokens, token)
+		}
+	}
+}
+
+// tokenizeText returns the arguments in the input. See Tokenize.
+func tokenizeText(input string) []string {
+	return arguments(Tokenize(input))
+}
+
+// arguments returns the value of each token.
+func arguments(tokens []Token) []string {
+	args := make([]string, len(tokens))
+	for i, token := range tokens {
+		args[i] = token.Value
+	}
+	return args
+}
+
+// A batchCommand is one of the commands in a message. See tokenizeCommands.
+type batchCommand struct {
+	tokens []Token
+	// filters holds the tokens of each filter that the command's output is piped through.
+	filters [][]Token
+	// conditional commands (i.e. following "&&") are only executed if the previous command succeeded.
+	conditional bool
+}
+
+// tokenizeCommands returns the tokens of the commands in the input.
+//
+// In batch mode, the input may contain several commands, separated by newlines, ";" or "&&". With pipes, the output
+// of a command may be piped through filters, separated by "|". Separators inside quotes or code blocks, or preceded by
+// a backslash, don't split the input.
+func tokenizeCommands(input string, batch bool, pipes bool) []batchCommand {
+	l := lexer{input: []rune(input), batch: batch, pipes: pipes}
+	commands := []batchCommand{{}}
+	for {
+		l.skipSpace()
+		if l.done() {
+			return commands
+		}
+		current := &commands[len(commands)-1]
+		if n := l.separator(l.pos); n > 0 {
+			conditional := l.input[l.pos] == '&'
+			l.pos += n
+			if len(current.tokens) == 0 && len(current.filters) == 0 {
+				// e.g. "foo &&\nbar": the newline doesn't start a new command
+				current.conditional = current.conditional || conditional
+			} else {
+				commands = append(commands, batchCommand{conditional: conditional})
+			}
+			continue
+		}
+		if l.pipe(l.pos) {
+			l.pos++
+			if len(current.filters) == 0 || len(current.filters[len(current.filters)-1]) > 0 {
+				current.filters = append(current.filters, nil)
+			}
+			continue
+		}
+		token, ok := l.token()
+		switch {
+		case !ok:
+		case len(current.filters) == 0:
+			current.tokens = append(current.tokens, token)
+		default:
+			current.filters[len(current.filters)-1] = append(current.filters[len(current.filters)-1], token)
+		}
+	}
+}
+
+type lexer struct {
+	input []rune
+	pos   int
+	// batch determines whether the input may contain several commands. See tokenizeCommands.
+	batch bool
+	// pipes determines whether the input may contain filters. See tokenizeCommands.
+	pipes bool
+}
+
+func (l *lexer) done() bool {
+	return l.pos >= len(l.input)
+}
+
+func (l *lexer) skipSpace() {
+	for !l.done() && unicode.IsSpace(l.input[l.pos]) && l.separator(l.pos) == 0 {
+		l.pos++
+	}
+}
+
+// at returns true if s occurs at pos.
+func (l *lexer) at(pos int, s string) bool {
+	target := []rune(s)
+	return pos+len(target) <= len(l.input) && slices.Equal(l.input[pos:pos+len(target)], target)
+}
+
+// find returns the position of the first occurrence of s at or after pos, or -1 if s isn't found.
+func (l *lexer) find(pos int, s string) int {
+	for ; pos < len(l.input); pos++ {
+		if l.at(pos, s) {
+			return pos
+		}
+	}
+	return -1
+}
+
+// separator returns the length of the separator between two commands at pos, or 0 if there's no separator.
+// Separators are only recognized in batch mode.
+func (l *lexer) separator(pos int) int {
+	if !l.batch {
+		return 0
+	}
+	for _, separator := range []string{"\n", ";", "&&", "&amp;&amp;"} {
+		if l.at(pos, separator) {
+			return len([]rune(separator))
+		}
+	}
+	return 0
+}
+
+// pipe returns true if there's a pipe at pos. Pipes are only recognized if pipes are enabled.
+func (l *lexer) pipe(pos int) bool {
+	return l.pipes && l.input[pos] == '|'
+}
+
+// wordEnds returns true if pos is at the end of the input, at whitespace, at a separator or at a pipe.
+func (l *lexer) wordEnds(pos int) bool {
+	return pos >= len(l.input) || unicode.IsSpace(l.input[pos]) || l.separator(pos) > 0 || l.pipe(pos)
+}
+
+// token returns the token at the current position. A token consists of one or more segments (text, quoted phrases,
+// code, markup). If the token consists of a single code or markup segment, the token has that segment's kind.
+// It returns false if the token is empty because it only consists of an unterminated quote.
+func (l *lexer) token() (Token, bool) {
+	if l.at(l.pos, "```") {
+		if end := l.find(l.pos+3, "```"); end >= 0 {
+			code := string(l.input[l.pos+3 : end])
+			l.pos = end + 3
+			code = strings.TrimPrefix(strings.TrimSuffix(code, "\n"), "\n")
+			return Token{Kind: TokenCode, Value: decodeEntities(code)}, true
+		}
+	}
+
+	var value strings.Builder
+	var segments int
+	var last Token
+	for !l.wordEnds(l.pos) {
+		segments++
+		last = Token{Kind: TokenWord}
+		r := l.input[l.pos]
+		switch {
+		case r == '\\' && l.pos+1 < len(l.input):
+			value.WriteRune(l.input[l.pos+1])
+			l.pos += 2
+		case r == '`':
+			if end := l.find(l.pos+1, "`"); end > l.pos+1 {
+				last = Token{Kind: TokenCode, Value: decodeEntities(string(l.input[l.pos+1 : end]))}
+				value.WriteString(last.Value)
+				l.pos = end + 1
+			} else {
+				value.WriteRune(r)
+				l.pos++
+			}
+		case r == '<':
+			if end := l.find(l.pos+1, ">"); end >= 0 {
+				last = parseMarkup(string(l.input[l.pos+1 : end]))
+				value.WriteString(last.Value)
+				l.pos = end + 1
+			} else {
+				value.WriteRune(r)
+				l.pos++
+			}
+		case r == '&':
+			text, n := decodeEntity(l.input[l.pos:])
+			value.WriteString(text)
+			l.pos += n
+		case isQuote(r, value.Len() == 0):
+			if quoted, ok := l.quoted(); ok {
+				value.WriteString(quoted)
+			} else {
+				// unterminated quote: ignore it
+				segments--
+				l.pos++
+			}
+		default:
+			value.WriteRune(r)
+			l.pos++
+		}
+	}
+	if segments == 1 && last.Kind != TokenWord {
+		return last, true
+	}
+	return Token{Kind: TokenWord, Value: value.String()}, segments > 0
+}
+
+// isQuote returns true if the rune opens a quoted phrase. Single quotes only open a quoted phrase at the start of a word,
+// so that apostrophes can be used inside words.
+func isQuote(r rune, start bool) bool {
+	switch r {
+	case '"', '“', '”':
+		return true
+	case '\'', '‘':
+		return start
+	default:
+		return false
+	}
+}
+
+// quoted reads the quoted phrase at the current position. It returns false if the quote isn't terminated.
+//
+// Slack clients may replace straight quotes with curly quotes (and vice versa), so these are interchangeable.
+// A single quote only closes the phrase at the end of a word.
+func (l *lexer) quoted() (string, bool) {
+	single := l.input[l.pos] == '\'' || l.input[l.pos] == '‘'
+	closing := `"”“`
+	if single {
+		closing = "'’"
+	}
+	var value strings.Builder
+	for pos := l.pos + 1; pos < len(l.input); pos++ {
+		r := l.input[pos]
+		switch {
+		case r == '\\' && pos+1 < len(l.input) && (l.input[pos+1] == '\\' || strings.ContainsRune(closing, l.input[pos+1])):
+			pos++
+			value.WriteRune(l.input[pos])
+		case strings.ContainsRune(closing, r) && (!single || l.wordEnds(pos+1)):
+			l.pos = pos + 1
+			return decodeText(value.String()), true
+		default:
+			value.WriteRune(r)
+		}
+	}
+	return "", false
+}
+
+// decodeText decodes the Slack markup in a quoted phrase.
+func decodeText(text string) string {
+	l := lexer{input: []rune(text)}
+	var value strings.Builder
+	for !l.done() {
+		switch l.input[l.pos] {
+		case '<':
+			if end := l.find(l.pos+1, ">"); end >= 0 {
+				value.WriteString(parseMarkup(string(l.input[l.pos+1 : end])).Value)
+				l.pos = end + 1
+				continue
+			}
+		case '&':
+			text, n := decodeEntity(l.input[l.pos:])
+			value.WriteString(text)
+			l.pos += n
+			continue
+		}
+		value.WriteRune(l.input[l.pos])
+		l.pos++
+	}
+	return value.String()
+}
+
+// parseMarkup parses Slack's markup for mentions, links and emails, i.e. the text between < and >.
+func parseMarkup(markup string) Token {
+	target, label, _ := strings.Cut(markup, "|")
+	target, label = decodeEntities(target), decodeEntities(label)
+	raw := "<" + markup + ">"
+	switch {
+	case strings.HasPrefix(target, "@"):
+		return Token{Kind: TokenUser, Value: raw, Target: target[1:], Label: label}
+	case strings.HasPrefix(target, "#"):
+		return Token{Kind: TokenChannel, Value: raw, Target: target[1:], Label: label}
+	case strings.HasPrefix(target, "!"):
+		return Token{Kind: TokenSpecial, Value: raw, Target: target[1:], Label: label}
+	case strings.HasPrefix(target, "mailto:"):
+		address := strings.TrimPrefix(target, "mailto:")
+		return Token{Kind: TokenEmail, Value: address, Target: address, Label: label}
+	default:
+		return Token{Kind: TokenLink, Value: target, Target: target, Label: label}
+	}
+}
+
+var entityReplacer = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+// decodeEntity decodes the entity at the start of the input. It returns the decoded text and the number of runes consumed.
+func decodeEntity(input []rune) (string, int) {
+	for _, entity := range []string{"&amp;", "&lt;", "&gt;"} {
+		if strings.HasPrefix(string(input), entity) {
+			return decodeEntities(entity), len(entity)
+		}
+	}
+	return string(input[0]), 1
+}
+
+// decodeEntities replaces the entities that Slack uses to encode &, < and >.
+func decodeEntities(text string) string {
+	return entityReplacer.Replace(text)
+}
@@ -0,0 +1,169 @@
+package slackapp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "empty",
+			input: " \n ",
+			want:  nil,
+		},
+		{
+			name:  "words",
+			input: "foo\tbar\nsnafu",
+			want:  []Token{{Value: "foo"}, {Value: "bar"}, {Value: "snafu"}},
+		},
+		{
+			name:  "unterminated lone quote",
+			input: `deploy ' “ foo`,
+			want:  []Token{{Value: "deploy"}, {Value: "foo"}},
+		},
+		{
+			name:  "empty quotes",
+			input: `deploy ""`,
+			want:  []Token{{Value: "deploy"}, {Value: ""}},
+		},
+		{
+			name:  "quoted phrases",
+			input: `"foo bar" 'bar snafu' “snafu foo” ‘foo’`,
+			want:  []Token{{Value: "foo bar"}, {Value: "bar snafu"}, {Value: "snafu foo"}, {Value: "foo"}},
+		},
+		{
+			name:  "nested quotes",
+			input: `"say 'hi'" 'say "hi"'`,
+			want:  []Token{{Value: "say 'hi'"}, {Value: `say "hi"`}},
+		},
+		{
+			name:  "apostrophes",
+			input: `don't 'it's fine'`,
+			want:  []Token{{Value: "don't"}, {Value: "it's fine"}},
+		},
+		{
+			name:  "quotes inside a word",
+			input: `--name="foo bar"`,
+			want:  []Token{{Value: "--name=foo bar"}},
+		},
+		{
+			name:  "escapes",
+			input: `foo\ bar \"snafu\" "a \"quoted\" \\word"`,
+			want:  []Token{{Value: "foo bar"}, {Value: `"snafu"`}, {Value: `a "quoted" \word`}},
+		},
+		{
+			name:  "unterminated quote",
+			input: `foo "bar snafu`,
+			want:  []Token{{Value: "foo"}, {Value: "bar"}, {Value: "snafu"}},
+		},
+		{
+			name:  "code block",
+			input: "run ```\nif a &amp;&amp; b {\n  echo \"foo bar\"\n}\n``` now",
+			want: []Token{
+				{Value: "run"},
+				{Kind: TokenCode, Value: "if a && b {\n  echo \"foo bar\"\n}"},
+				{Value: "now"},
+			},
+		},
+		{
+			name:  "inline code",
+			input: "grep `foo  bar` `x`=1",
+			want:  []Token{{Value: "grep"}, {Kind: TokenCode, Value: "foo  bar"}, {Value: "x=1"}},
+		},
+		{
+			name:  "entities",
+			input: "a&amp;b &lt;tag&gt; &foo",
+			want:  []Token{{Value: "a&b"}, {Value: "<tag>"}, {Value: "&foo"}},
+		},
+		{
+			name:  "mentions",
+			input: "<@U123> <#C123|general> <!here> <!subteam^S123|@team>",
+			want: []Token{
+				{Kind: TokenUser, Value: "<@U123>", Target: "U123"},
+				{Kind: TokenChannel, Value: "<#C123|general>", Target: "C123", Label: "general"},
+				{Kind: TokenSpecial, Value: "<!here>", Target: "here"},
+				{Kind: TokenSpecial, Value: "<!subteam^S123|@team>", Target: "subteam^S123", Label: "@team"},
+			},
+		},
+		{
+			name:  "links",
+			input: "<https://example.com/?a=1&amp;b=2|example> <mailto:foo@example.com|foo@example.com> url=<https://example.com>",
+			want: []Token{
+				{Kind: TokenLink, Value: "https://example.com/?a=1&b=2", Target: "https://example.com/?a=1&b=2", Label: "example"},
+				{Kind: TokenEmail, Value: "foo@example.com", Target: "foo@example.com", Label: "foo@example.com"},
+				{Value: "url=https://example.com"},
+			},
+		},
+		{
+			name:  "markup in quotes",
+			input: `"see <https://example.com|here> &amp; there"`,
+			want:  []Token{{Value: "see https://example.com & there"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Tokenize(tt.input))
+		})
+	}
+}
+
+func Test_tokenizeCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []batchCommand
+	}{
+		{
+			name:  "single command",
+			input: "foo bar",
+			want:  []batchCommand{{tokens: []Token{{Value: "foo"}, {Value: "bar"}}}},
+		},
+		{
+			name:  "separators",
+			input: "foo;bar && snafu\n\nfoo &amp;&amp;\nbar",
+			want: []batchCommand{
+				{tokens: []Token{{Value: "foo"}}},
+				{tokens: []Token{{Value: "bar"}}},
+				{tokens: []Token{{Value: "snafu"}}, conditional: true},
+				{tokens: []Token{{Value: "foo"}}},
+				{tokens: []Token{{Value: "bar"}}, conditional: true},
+			},
+		},
+		{
+			name:  "quoted and escaped separators",
+			input: "foo \"a;b && c\" d\\;e 'f\ng'",
+			want:  []batchCommand{{tokens: []Token{{Value: "foo"}, {Value: "a;b && c"}, {Value: "d;e"}, {Value: "f\ng"}}}},
+		},
+		{
+			name:  "pipes",
+			input: "logs api | grep \"a|b\" <https://example.com|example> || tail 20 && foo|",
+			want: []batchCommand{
+				{tokens: []Token{{Value: "logs"}, {Value: "api"}}, filters: [][]Token{
+					{{Value: "grep"}, {Value: "a|b"}, {Kind: TokenLink, Value: "https://example.com", Target: "https://example.com", Label: "example"}},
+					{{Value: "tail"}, {Value: "20"}},
+				}},
+				{tokens: []Token{{Value: "foo"}}, filters: [][]Token{nil}, conditional: true},
+			},
+		},
+		{
+			name:  "code block",
+			input: "run ```\na;\nb\n```; foo",
+			want: []batchCommand{
+				{tokens: []Token{{Value: "run"}, {Kind: TokenCode, Value: "a;\nb"}}},
+				{tokens: []Token{{Value: "foo"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenizeCommands(tt.input, true, true))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A Listener reacts to messages in channels that match its Pattern, without the bot being mentioned. E.g. a Listener
+// can expand issue keys (e.g. "JIRA-1234") into links:
+//
+//	slackapp.Listener{
+//		Pattern: regexp.MustCompile(`\b([A-Z]+-\d+)\b`),
+//		Handler: slackapp.HandlerFunc(func(ctx context.Context, args ...string) []slack.MsgOption {
+//			return []slack.MsgOption{slack.MsgOptionText("<https://jira.example.com/browse/"+args[0]+"|"+args[0]+">", false)}
+//		}),
+//	}
+//
+// The Bot calls the Handler for each (distinct) match in the message, with the Pattern's capturing groups as arguments,
+// or the matched text if the Pattern has no capturing groups. The Handler's output is posted in the message's thread
+// if the message is in a thread, and in the channel otherwise.
+//
+// To prevent loops, Listeners ignore messages posted by bots (including the Bot itself). Messages that mention the bot
+// are executed as commands instead.
+type Listener struct {
+	Pattern *regexp.Regexp
+	Handler Handler
+	// Channels holds the IDs of the channels where the Listener is enabled. If empty, it's enabled in all channels.
+	Channels []string
+}
+
+// onMessage processes a message posted in a channel (or direct message) the bot is in. Edited commands are re-executed
+// (see onEdit), the replies to deleted commands are deleted (see onDelete) and messages in an active session's thread
+// are passed to the session. Otherwise, if the Bot has Listeners, messages in channels are passed to the Listeners; all
+// other messages are executed as commands.
+func (b *Bot) onMessage(ctx context.Context, teamID string, data *slackevents.MessageEvent) {
+	botUserID, err := b.botUserID(ctx, teamID)
+	if err != nil {
+		b.logger.Warn("failed to determine bot user", "team", teamID, "err", err)
+		return
+	}
+	// don't process our own messages
+	if data.User == botUserID {
+		return
+	}
+	switch data.SubType {
+	case "message_changed":
+		b.onEdit(ctx, teamID, data)
+		return
+	case "message_deleted":
+		b.onDelete(ctx, teamID, data)
+		return
+	}
+	req := Request{TeamID: teamID, ChannelID: data.Channel, UserID: data.User, TS: data.TimeStamp, ThreadTS: data.ThreadTimeStamp}
+	if data.SubType == "" && b.converse(ctx, req, data.Text) {
+		return
+	}
+	if len(b.listeners) > 0 && data.ChannelType != "im" {
+		if data.SubType == "" && data.BotID == "" && !strings.Contains(data.Text, "<@"+botUserID+">") {
+			b.hear(ctx, req, decodeEntities(data.Text))
+		}
+		return
+	}
+	_ = b.handle(ctx, req, data.Text)
+}
+
+// hear calls the Listeners matching the text. The Bot remembers its replies, so they can be deleted along with the
+// message (see WithReplyDeletion).
+func (b *Bot) hear(ctx context.Context, req Request, text string) {
+	t := replyTracker{channelID: req.ChannelID}
+	ctx = withReplyTracker(ctx, &t)
+	for _, listener := range b.listeners {
+		if len(listener.Channels) > 0 && !slices.Contains(listener.Channels, req.ChannelID) {
+			continue
+		}
+		var heard [][]string
+		for _, match := range listener.Pattern.FindAllStringSubmatch(text, -1) {
+			args := match[1:]
+			if len(args) == 0 {
+				args = match[:1]
+			}
+			if slices.ContainsFunc(heard, func(h []string) bool { return slices.Equal(h, args) }) {
+				continue
+			}
+			heard = append(heard, args)
+			b.logger.Debug("heard message", "channel", req.ChannelID, "pattern", listener.Pattern.String())
+			output, _ := b.execute(ctx, req, func(ctx context.Context) []slack.MsgOption {
+				return listener.Handler.Handle(ctx, args...)
+			})
+			if err := b.postAll(ctx, req, inThread(output, req.ThreadTS)); err != nil {
+				b.logger.Warn("failed to post listener output", "channel", req.ChannelID, "err", err)
+			}
+		}
+	}
+	if len(t.sent) > 0 && req.TS != "" {
+		b.replies.set(req, reply{userID: req.UserID, sent: t.sent, heard: true})
+	}
+}
@@ -0,0 +1,97 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBot_Listener(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, ","), false)}
+	})
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithListener(Listener{Pattern: regexp.MustCompile(`\b([A-Z]+)-(\d+)\b`), Handler: echo}),
+		WithListener(Listener{Pattern: regexp.MustCompile(`(?i)\bdeploy(?:ed|ing)?\b`), Handler: echo, Channels: []string{"C2"}}),
+		WithListener(Listener{Pattern: regexp.MustCompile(`a&b`), Handler: echo}),
+		WithCommand("echo", echo),
+	)
+
+	tests := []struct {
+		name string
+		data slackevents.MessageEvent
+		want []url.Values
+	}{
+		{
+			name: "capture groups",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "see JIRA-1234, JIRA-1234 and OPS-1", TimeStamp: "1.0"},
+			want: []url.Values{{"text": {"JIRA,1234"}}, {"text": {"OPS,1"}}},
+		},
+		{
+			name: "thread",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "JIRA-1", TimeStamp: "2.0", ThreadTimeStamp: "1.0"},
+			want: []url.Values{{"text": {"JIRA,1"}, "thread_ts": {"1.0"}}},
+		},
+		{
+			name: "enabled channel",
+			data: slackevents.MessageEvent{Channel: "C2", ChannelType: "channel", User: "U1", Text: "Deployed!"},
+			want: []url.Values{{"text": {"Deployed"}}},
+		},
+		{
+			name: "disabled channel",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "Deployed!"},
+		},
+		{
+			name: "entities",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "a&amp;b"},
+			want: []url.Values{{"text": {"a&b"}}},
+		},
+		{
+			name: "bot message",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", BotID: "B1", Text: "JIRA-1"},
+		},
+		{
+			name: "own message",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "W23456789", Text: "JIRA-1"},
+		},
+		{
+			name: "edited message",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", SubType: "message_changed", Text: "JIRA-1"},
+		},
+		{
+			name: "mention",
+			data: slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "<@W23456789> echo JIRA-1"},
+		},
+		{
+			name: "direct message",
+			data: slackevents.MessageEvent{Channel: "D1", ChannelType: "im", User: "U1", Text: "echo JIRA-1"},
+			want: []url.Values{{"text": {"JIRA-1"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.onMessage(context.Background(), "", &tt.data)
+			for _, want := range tt.want {
+				post := <-ts.post
+				assert.Equal(t, tt.data.Channel, post.Get("channel"))
+				for k := range want {
+					assert.Equal(t, want.Get(k), post.Get(k), k)
+				}
+			}
+			assert.Empty(t, ts.post)
+		})
+	}
+}
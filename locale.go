@@ -0,0 +1,155 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"regexp"
+	"strings"
+)
+
+// A Catalog holds the translations of the Bot's messages, keyed by locale and message. Locales are language tags, as
+// used by Slack (e.g. "fr-FR"). Translations for a language (e.g. "fr") apply to all its regional variants.
+//
+// Messages are keyed by their English text, which is used when no translation exists. Messages with arguments are
+// format strings (see fmt.Sprintf), e.g.:
+//
+//	slackapp.Catalog{
+//		"fr": {
+//			"invalid command":        "commande invalide",
+//			"supported commands: %s": "commandes disponibles : %s",
+//		},
+//	}
+//
+// The Bot's built-in replies use the same Catalog. Handlers translate their own messages with Translate.
+type Catalog map[string]map[string]string
+
+// translate returns the translation of the message for the locale, or the message itself if no translation exists.
+func (c Catalog) translate(locale string, message string) string {
+	for locale != "" {
+		if translation, ok := c[locale][message]; ok {
+			return translation
+		}
+		// "fr-FR" falls back to "fr"
+		index := strings.LastIndexAny(locale, "-_")
+		if index < 0 {
+			break
+		}
+		locale = locale[:index]
+	}
+	return message
+}
+
+type localizer struct {
+	catalog Catalog
+	locale  string
+}
+
+type localizerKey struct{}
+
+func withLocalizer(ctx context.Context, catalog Catalog, locale string) context.Context {
+	return context.WithValue(ctx, localizerKey{}, localizer{catalog: catalog, locale: locale})
+}
+
+// Translate returns the message, translated for the user that issued the command and formatted with the args (see
+// fmt.Sprintf). If the Bot has no translation for the message (or the command wasn't issued through a Bot), it formats
+// the message itself.
+func Translate(ctx context.Context, message string, args ...any) string {
+	if l, ok := ctx.Value(localizerKey{}).(localizer); ok {
+		message = l.catalog.translate(l.locale, message)
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// LocaleFromContext returns the locale of the user that issued the command, e.g. "en-US". It returns false if the locale
+// is unknown, or if the Bot wasn't created with WithLocalization.
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	l, ok := ctx.Value(localizerKey{}).(localizer)
+	return l.locale, ok && l.locale != ""
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// withLocale adds the user's locale to the context, so Translate can translate messages for the user. If the Bot has
+// no Catalog, it returns the context as is.
+func (b *Bot) withLocale(ctx context.Context, teamID string, userID string) context.Context {
+	if b.catalog == nil {
+		return ctx
+	}
+	return withLocalizer(ctx, b.catalog, b.locale(ctx, teamID, userID))
+}
+
+// locale returns the user's locale: the language set with the "language" command or, if not set, the user's locale in
+// Slack. It returns an empty string if the locale is unknown.
+func (b *Bot) locale(ctx context.Context, teamID string, userID string) string {
+	if userID == "" {
+		return ""
+	}
+	if preference, err := b.languages.Get(ctx, localeKey(teamID, userID)); err == nil {
+		return string(preference)
+	} else if !errors.Is(err, ErrNotFound) {
+		b.logger.Warn("failed to get language", "user", userID, "err", err)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if locale, ok := b.locales[localeKey(teamID, userID)]; ok {
+		return locale
+	}
+	client, err := b.client(ctx, teamID)
+	if err != nil {
+		b.logger.Warn("failed to get user locale", "user", userID, "err", err)
+		return ""
+	}
+	user, err := client.GetUserInfoContext(ctx, userID)
+	if err != nil {
+		b.logger.Warn("failed to get user locale", "user", userID, "err", err)
+		return ""
+	}
+	b.locales[localeKey(teamID, userID)] = user.Locale
+	return user.Locale
+}
+
+func localeKey(teamID string, userID string) string {
+	if teamID == "" {
+		teamID = "default"
+	}
+	return teamID + "/" + userID
+}
+
+var languageTag = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// language shows or sets the user's language:
+//
+//	language          shows the user's language
+//	language <locale> sets the user's language, e.g. "fr" or "pt-BR"
+//	language auto     uses the user's locale in Slack
+func (b *Bot) language(ctx context.Context, args ...string) []slack.MsgOption {
+	req, _ := RequestFromContext(ctx)
+	key := localeKey(req.TeamID, req.UserID)
+	switch {
+	case len(args) == 0:
+		if locale, ok := LocaleFromContext(ctx); ok {
+			return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "your language is %s", locale), false)}
+		}
+		return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "your language is not set"), false)}
+	case len(args) == 1 && args[0] == "auto":
+		if err := b.languages.Delete(ctx, key); err != nil {
+			b.logger.Warn("failed to reset language", "user", req.UserID, "err", err)
+		}
+		ctx = b.withLocale(ctx, req.TeamID, req.UserID)
+		return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "using your language in Slack"), false)}
+	case len(args) == 1 && languageTag.MatchString(args[0]):
+		if err := b.languages.Set(ctx, key, []byte(args[0])); err != nil {
+			b.logger.Warn("failed to set language", "user", req.UserID, "err", err)
+		}
+		ctx = withLocalizer(ctx, b.catalog, args[0])
+		return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "your language is now %s", args[0]), false)}
+	default:
+		return errorMessage(ctx, "invalid arguments", Translate(ctx, "usage: language [<locale>|auto]"))
+	}
+}
@@ -0,0 +1,77 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	catalog := Catalog{
+		"fr":    {"no results": "aucun résultat", "removed job %s": "tâche %s supprimée"},
+		"fr-CA": {"no results": "pas de résultats"},
+	}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		message string
+		args    []any
+		want    string
+	}{
+		{name: "no catalog", ctx: context.Background(), message: "removed job %s", args: []any{"1"}, want: "removed job 1"},
+		{name: "locale", ctx: withLocalizer(context.Background(), catalog, "fr-CA"), message: "no results", want: "pas de résultats"},
+		{name: "language", ctx: withLocalizer(context.Background(), catalog, "fr-FR"), message: "no results", want: "aucun résultat"},
+		{name: "arguments", ctx: withLocalizer(context.Background(), catalog, "fr_CA"), message: "removed job %s", args: []any{"1"}, want: "tâche 1 supprimée"},
+		{name: "no translation", ctx: withLocalizer(context.Background(), catalog, "de-DE"), message: "no results", want: "no results"},
+		{name: "no locale", ctx: withLocalizer(context.Background(), catalog, ""), message: "100%", want: "100%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Translate(tt.ctx, tt.message, tt.args...))
+		})
+	}
+}
+
+func TestBot_Localization(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithLocalization(Catalog{
+			"fr": {
+				"invalid arguments":               "arguments invalides",
+				"your language is %s":             "votre langue est %s",
+				"using your language in Slack":    "vous utilisez votre langue de Slack",
+				"usage: language [<locale>|auto]": "usage : language [<langue>|auto]",
+			},
+			"nl": {"your language is now %s": "uw taal is nu %s"},
+		}),
+		WithLanguageCommand(),
+	)
+	req := Request{ChannelID: "C1", UserID: "U1"}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "<@W23456789> language", want: "votre langue est fr-FR"},
+		{input: "<@W23456789> language nl", want: "uw taal is nu nl"},
+		{input: "<@W23456789> language", want: "your language is nl"},
+		{input: "<@W23456789> language auto", want: "vous utilisez votre langue de Slack"},
+	}
+	for _, tt := range tests {
+		require.NoError(t, b.handle(context.Background(), req, tt.input))
+		assert.Equal(t, tt.want, (<-ts.post).Get("text"), tt.input)
+	}
+
+	require.NoError(t, b.handle(context.Background(), req, "<@W23456789> language 'not a locale'"))
+	assert.Equal(t, `[{"color":"bad","title":"arguments invalides","text":"usage : language [\u003clangue\u003e|auto]","blocks":null}]`, (<-ts.post).Get("attachments"))
+}
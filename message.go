@@ -0,0 +1,143 @@
+package slackapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/slack-go/slack"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// A message holds the content of a message, as set by its slack.MsgOption items.
+type message struct {
+	text        string
+	attachments []slack.Attachment
+	blocks      slack.Blocks
+	threadTS    string
+}
+
+// parseMessage returns the content set by the options. Options that don't set the content (e.g. disabling unfurling)
+// are ignored.
+func parseMessage(options ...slack.MsgOption) (message, error) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	if err != nil {
+		return message{}, err
+	}
+	m := message{text: values.Get("text"), threadTS: values.Get("thread_ts")}
+	if attachments := values.Get("attachments"); attachments != "" {
+		if err = json.Unmarshal([]byte(attachments), &m.attachments); err != nil {
+			return message{}, fmt.Errorf("attachments: %w", err)
+		}
+	}
+	if blocks := values.Get("blocks"); blocks != "" {
+		if err = json.Unmarshal([]byte(blocks), &m.blocks); err != nil {
+			return message{}, fmt.Errorf("blocks: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// options returns the slack.MsgOption items that set the message's content.
+func (m message) options() []slack.MsgOption {
+	var options []slack.MsgOption
+	if m.text != "" {
+		options = append(options, slack.MsgOptionText(m.text, false))
+	}
+	if len(m.attachments) > 0 {
+		options = append(options, slack.MsgOptionAttachments(m.attachments...))
+	}
+	if len(m.blocks.BlockSet) > 0 {
+		options = append(options, slack.MsgOptionBlocks(m.blocks.BlockSet...))
+	}
+	if m.threadTS != "" {
+		options = append(options, slack.MsgOptionTS(m.threadTS))
+	}
+	return options
+}
+
+// empty returns true if the message has no content.
+func (m message) empty() bool {
+	return m.text == "" && len(m.attachments) == 0 && len(m.blocks.BlockSet) == 0
+}
+
+// Slack's limits for a message.
+const (
+	maxTextLength = 40000
+	maxBlocks     = 50
+)
+
+// oversized returns true if the message exceeds Slack's limits.
+func (m message) oversized() bool {
+	return utf8.RuneCountInString(m.text) > maxTextLength || len(m.blocks.BlockSet) > maxBlocks
+}
+
+// split splits the message into messages that don't exceed Slack's limits. The attachments are added to the first message.
+func (m message) split() []message {
+	texts := splitText(m.text, maxTextLength)
+	blocks := slices.Collect(slices.Chunk(m.blocks.BlockSet, maxBlocks))
+	parts := make([]message, max(len(texts), len(blocks), 1))
+	for i := range parts {
+		if i < len(texts) {
+			parts[i].text = texts[i]
+		}
+		if i < len(blocks) {
+			parts[i].blocks.BlockSet = blocks[i]
+		}
+		parts[i].threadTS = m.threadTS
+	}
+	parts[0].attachments = m.attachments
+	return parts
+}
+
+// splitText splits the text in parts of at most size characters, preferably at the end of a line. If the text is a
+// code block, each part is a code block.
+func splitText(text string, size int) []string {
+	if text == "" {
+		return nil
+	}
+	content, isCode := codeBlockContent(text)
+	if isCode {
+		size -= len(codeBlock(""))
+	}
+	var parts []string
+	for runes := []rune(content); len(runes) > 0; {
+		n := min(size, len(runes))
+		if n < len(runes) {
+			// split after the last newline, if any
+			for index := n - 1; index > 0; index-- {
+				if runes[index] == '\n' {
+					n = index + 1
+					break
+				}
+			}
+		}
+		part := strings.TrimSuffix(string(runes[:n]), "\n")
+		if isCode {
+			part = codeBlock(part)
+		}
+		parts = append(parts, part)
+		runes = runes[n:]
+	}
+	return parts
+}
+
+// combineMessages returns a single message with the content of all messages: their text (one per line), attachments
+// and blocks. Options that don't set the content are dropped.
+func combineMessages(messages ...[]slack.MsgOption) ([]slack.MsgOption, error) {
+	var combined message
+	var text []string
+	for _, options := range messages {
+		m, err := parseMessage(options...)
+		if err != nil {
+			return nil, err
+		}
+		if m.text != "" {
+			text = append(text, m.text)
+		}
+		combined.attachments = append(combined.attachments, m.attachments...)
+		combined.blocks.BlockSet = append(combined.blocks.BlockSet, m.blocks.BlockSet...)
+	}
+	combined.text = strings.Join(text, "\n")
+	return combined.options(), nil
+}
@@ -0,0 +1,75 @@
+package slackapp
+
+import (
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func Test_combineMessages(t *testing.T) {
+	options, err := combineMessages(
+		[]slack.MsgOption{slack.MsgOptionText("foo", false), slack.MsgOptionDisableLinkUnfurl()},
+		[]slack.MsgOption{slack.MsgOptionAttachments(slack.Attachment{Title: "bar"})},
+		[]slack.MsgOption{
+			slack.MsgOptionText("snafu", false),
+			slack.MsgOptionBlocks(slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "block", false, false), nil, nil)),
+		},
+	)
+	require.NoError(t, err)
+
+	output := formatMessage(options)
+	assert.Equal(t, "foo\nsnafu", output.Get("text"))
+	assert.Equal(t, `[{"title":"bar","blocks":null}]`, output.Get("attachments"))
+	assert.Equal(t, `[{"type":"section","text":{"type":"mrkdwn","text":"block"}}]`, output.Get("blocks"))
+	assert.Empty(t, output.Get("unfurl_links"))
+}
+
+func Test_splitText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		size int
+		want []string
+	}{
+		{name: "empty", text: "", size: 10, want: nil},
+		{name: "fits", text: "foo\nbar", size: 10, want: []string{"foo\nbar"}},
+		{name: "lines", text: "foo\nbar\nsnafu", size: 9, want: []string{"foo\nbar", "snafu"}},
+		{name: "long line", text: "foobarsnafu", size: 4, want: []string{"foob", "arsn", "afu"}},
+		{name: "code block", text: "```\nfoo\nbar\n```", size: 12, want: []string{"```\nfoo\n```", "```\nbar\n```"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitText(tt.text, tt.size))
+		})
+	}
+}
+
+func Test_message_split(t *testing.T) {
+	blocks := make([]slack.Block, 2*maxBlocks+1)
+	for i := range blocks {
+		blocks[i] = slack.NewDividerBlock()
+	}
+	m := message{
+		text:        strings.Repeat("a", maxTextLength+1),
+		attachments: []slack.Attachment{{Title: "foo"}},
+		blocks:      slack.Blocks{BlockSet: blocks},
+		threadTS:    "1.0",
+	}
+	require.True(t, m.oversized())
+
+	parts := m.split()
+	require.Len(t, parts, 3)
+	assert.Len(t, parts[0].text, maxTextLength)
+	assert.Len(t, parts[1].text, 1)
+	assert.Empty(t, parts[2].text)
+	assert.Len(t, parts[0].attachments, 1)
+	assert.Empty(t, parts[1].attachments)
+	assert.Len(t, parts[0].blocks.BlockSet, maxBlocks)
+	assert.Len(t, parts[2].blocks.BlockSet, 1)
+	for _, part := range parts {
+		assert.False(t, part.oversized())
+		assert.Equal(t, "1.0", part.threadTS)
+	}
+}
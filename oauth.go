@@ -0,0 +1,269 @@
+package slackapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Installation holds the bot token for a workspace where the app is installed. If token rotation is enabled for the app,
+// it also holds the refresh token and the time when the bot token expires.
+type Installation struct {
+	TeamID       string    `json:"team_id"`
+	TeamName     string    `json:"team_name"`
+	BotUserID    string    `json:"bot_user_id"`
+	BotToken     string    `json:"bot_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	Scope        string    `json:"scope"`
+	Installed    time.Time `json:"installed"`
+}
+
+// A TokenStore keeps the Installation for each workspace where the app is installed.
+type TokenStore interface {
+	// Get returns the Installation for the workspace. It returns ErrNotFound if the app isn't installed in the workspace.
+	Get(ctx context.Context, teamID string) (Installation, error)
+	// Save adds or updates the Installation for its workspace.
+	Save(ctx context.Context, installation Installation) error
+	// Delete removes the Installation for the workspace.
+	Delete(ctx context.Context, teamID string) error
+}
+
+// NewTokenStore returns a TokenStore that keeps the Installations in the Store.
+func NewTokenStore(store Store) TokenStore {
+	return storeTokenStore{store: store}
+}
+
+type storeTokenStore struct {
+	store Store
+}
+
+func (s storeTokenStore) Get(ctx context.Context, teamID string) (Installation, error) {
+	var installation Installation
+	body, err := s.store.Get(ctx, teamID)
+	if err == nil {
+		err = json.Unmarshal(body, &installation)
+	}
+	return installation, err
+}
+
+func (s storeTokenStore) Save(ctx context.Context, installation Installation) error {
+	body, err := json.Marshal(installation)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, installation.TeamID, body)
+}
+
+func (s storeTokenStore) Delete(ctx context.Context, teamID string) error {
+	return s.store.Delete(ctx, teamID)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// An Installer implements Slack's OAuth v2 flow to install the app in a workspace. Mount InstallHandler and RedirectHandler
+// on the application's HTTP server: InstallHandler redirects the user to Slack to approve the installation. Slack then
+// redirects the user to RedirectURL, served by RedirectHandler, which exchanges the temporary code for a bot token and
+// saves the resulting Installation in Tokens.
+type Installer struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Tokens       TokenStore
+	// HTTPClient is used to exchange the code for a token. The default is http.DefaultClient.
+	HTTPClient *http.Client
+	Logger     *slog.Logger
+	// AuthorizeURL is Slack's authorization URL. The default is https://slack.com/oauth/v2/authorize.
+	AuthorizeURL string
+}
+
+const (
+	defaultAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+	oauthStateCookie    = "slackapp_oauth_state"
+)
+
+// InstallURL returns the URL that starts the installation of the app. The state is passed back to RedirectURL, to protect
+// against cross-site request forgery.
+func (i *Installer) InstallURL(state string) string {
+	authorizeURL := i.AuthorizeURL
+	if authorizeURL == "" {
+		authorizeURL = defaultAuthorizeURL
+	}
+	values := url.Values{
+		"client_id": {i.ClientID},
+		"scope":     {strings.Join(i.Scopes, ",")},
+		"state":     {state},
+	}
+	if i.RedirectURL != "" {
+		values.Set("redirect_uri", i.RedirectURL)
+	}
+	return authorizeURL + "?" + values.Encode()
+}
+
+// InstallHandler returns an http.Handler that redirects the user to Slack to install the app. It generates a random
+// state, stored in a cookie, which RedirectHandler verifies.
+func (i *Installer) InstallHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := randomState()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     oauthStateCookie,
+			Value:    state,
+			Path:     "/",
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, i.InstallURL(state), http.StatusFound)
+	})
+}
+
+// RedirectHandler returns an http.Handler that completes the installation: it verifies the state, exchanges the code
+// for a bot token (using oauth.v2.access) and saves the Installation.
+func (i *Installer) RedirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if reason := query.Get("error"); reason != "" {
+			http.Error(w, "installation failed: "+reason, http.StatusForbidden)
+			return
+		}
+		cookie, err := r.Cookie(oauthStateCookie)
+		if err != nil || cookie.Value == "" || cookie.Value != query.Get("state") {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: "/", MaxAge: -1})
+
+		installation, err := i.Exchange(r.Context(), query.Get("code"))
+		if err != nil {
+			i.logger().Error("failed to install app", "err", err)
+			http.Error(w, "installation failed", http.StatusInternalServerError)
+			return
+		}
+		i.logger().Info("app installed", "team", installation.TeamID, "name", installation.TeamName)
+		_, _ = w.Write([]byte("The app was installed in " + installation.TeamName + ". You can close this window."))
+	})
+}
+
+// Exchange exchanges the temporary code for a bot token and saves the Installation.
+func (i *Installer) Exchange(ctx context.Context, code string) (Installation, error) {
+	if code == "" {
+		return Installation{}, errors.New("missing code")
+	}
+	resp, err := slack.GetOAuthV2ResponseContext(ctx, i.httpClient(), i.ClientID, i.ClientSecret, code, i.RedirectURL)
+	if err != nil {
+		return Installation{}, fmt.Errorf("oauth.v2.access: %w", err)
+	}
+	installation := Installation{
+		TeamID:       resp.Team.ID,
+		TeamName:     resp.Team.Name,
+		BotUserID:    resp.BotUserID,
+		BotToken:     resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    expiresAt(resp.ExpiresIn),
+		Scope:        resp.Scope,
+		Installed:    time.Now(),
+	}
+	if err = i.Tokens.Save(ctx, installation); err != nil {
+		return Installation{}, fmt.Errorf("save: %w", err)
+	}
+	return installation, nil
+}
+
+func (i *Installer) httpClient() *http.Client {
+	if i.HTTPClient != nil {
+		return i.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (i *Installer) logger() *slog.Logger {
+	if i.Logger != nil {
+		return i.Logger
+	}
+	return slog.Default()
+}
+
+func randomState() (string, error) {
+	state := make([]byte, 16)
+	if _, err := rand.Read(state); err != nil {
+		return "", fmt.Errorf("state: %w", err)
+	}
+	return hex.EncodeToString(state), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// A ClientProvider returns the Slack client to use for a workspace.
+type ClientProvider interface {
+	Client(ctx context.Context, teamID string) (*slack.Client, error)
+}
+
+type staticClientProvider struct {
+	client *slack.Client
+}
+
+func (s staticClientProvider) Client(_ context.Context, _ string) (*slack.Client, error) {
+	return s.client, nil
+}
+
+// NewTokenStoreClientProvider returns a ClientProvider that creates a client for each workspace, using the workspace's
+// bot token from the TokenStore. If token rotation is enabled, use NewTokenClientProvider with a TokenRefresher instead.
+func NewTokenStoreClientProvider(tokens TokenStore, options ...slack.Option) ClientProvider {
+	return NewTokenClientProvider(TokenProviderFunc(func(ctx context.Context, teamID string) (string, error) {
+		installation, err := tokens.Get(ctx, teamID)
+		if err != nil {
+			return "", fmt.Errorf("team %s: %w", teamID, err)
+		}
+		return installation.BotToken, nil
+	}), options...)
+}
+
+// NewTokenClientProvider returns a ClientProvider that creates a client for each workspace, using the token returned
+// by the TokenProvider. Clients are cached until the workspace's token changes: when a token is refreshed, the client
+// is transparently replaced.
+func NewTokenClientProvider(tokens TokenProvider, options ...slack.Option) ClientProvider {
+	return &tokenClientProvider{tokens: tokens, options: options, clients: make(map[string]cachedClient)}
+}
+
+type tokenClientProvider struct {
+	tokens  TokenProvider
+	options []slack.Option
+	clients map[string]cachedClient
+	lock    sync.Mutex
+}
+
+type cachedClient struct {
+	token  string
+	client *slack.Client
+}
+
+func (p *tokenClientProvider) Client(ctx context.Context, teamID string) (*slack.Client, error) {
+	token, err := p.tokens.Token(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if cached, ok := p.clients[teamID]; ok && cached.token == token {
+		return cached.client, nil
+	}
+	client := slack.New(token, p.options...)
+	p.clients[teamID] = cachedClient{token: token, client: client}
+	return client, nil
+}
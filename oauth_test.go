@@ -0,0 +1,181 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/clambin/slackapp/internal/testutils"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestInstaller(t *testing.T) {
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/api/oauth.v2.access" || r.Form.Get("code") != "valid-code" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_code"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"access_token":"xoxb-1","scope":"chat:write","bot_user_id":"U1","team":{"id":"T1","name":"team 1"}}`))
+	}))
+	defer slackServer.Close()
+
+	tokens := NewTokenStore(&MemoryStore{})
+	installer := Installer{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://example.com/slack/redirect",
+		Scopes:       []string{"app_mentions:read", "chat:write"},
+		Tokens:       tokens,
+		HTTPClient:   &http.Client{Transport: redirectTransport{target: slackServer.URL}},
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// install handler redirects to Slack
+	w := httptest.NewRecorder()
+	installer.InstallHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slack/install", nil))
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "slack.com", location.Host)
+	assert.Equal(t, "client-id", location.Query().Get("client_id"))
+	assert.Equal(t, "app_mentions:read,chat:write", location.Query().Get("scope"))
+	assert.Equal(t, installer.RedirectURL, location.Query().Get("redirect_uri"))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	state := location.Query().Get("state")
+	require.Equal(t, state, cookies[0].Value)
+
+	tests := []struct {
+		name     string
+		query    string
+		cookie   string
+		wantCode int
+	}{
+		{name: "user denied", query: "error=access_denied", wantCode: http.StatusForbidden},
+		{name: "missing state", query: "code=valid-code", wantCode: http.StatusBadRequest},
+		{name: "invalid state", query: "code=valid-code&state=foo", cookie: state, wantCode: http.StatusBadRequest},
+		{name: "invalid code", query: "code=invalid-code&state=" + state, cookie: state, wantCode: http.StatusInternalServerError},
+		{name: "missing code", query: "state=" + state, cookie: state, wantCode: http.StatusInternalServerError},
+		{name: "valid", query: "code=valid-code&state=" + state, cookie: state, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/slack/redirect?"+tt.query, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			installer.RedirectHandler().ServeHTTP(w, r)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+
+	installation, err := tokens.Get(context.Background(), "T1")
+	require.NoError(t, err)
+	assert.Equal(t, "xoxb-1", installation.BotToken)
+	assert.Equal(t, "U1", installation.BotUserID)
+	assert.Equal(t, "team 1", installation.TeamName)
+
+	require.NoError(t, tokens.Delete(context.Background(), "T1"))
+	_, err = tokens.Get(context.Background(), "T1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// redirectTransport sends all requests to the target server.
+type redirectTransport struct {
+	target string
+}
+
+func (r redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(r.target)
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTokenStoreClientProvider(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewTokenStore(&MemoryStore{})
+	p := NewTokenStoreClientProvider(tokens)
+
+	_, err := p.Client(ctx, "T1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxb-1"}))
+	c1, err := p.Client(ctx, "T1")
+	require.NoError(t, err)
+	c2, err := p.Client(ctx, "T1")
+	require.NoError(t, err)
+	assert.Same(t, c1, c2)
+
+	// a new token creates a new client
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxb-2"}))
+	c2, err = p.Client(ctx, "T1")
+	require.NoError(t, err)
+	assert.NotSame(t, c1, c2)
+}
+
+func TestBot_MultipleWorkspaces(t *testing.T) {
+	posts := make(chan string)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		token := r.Form.Get("token")
+		if token == "" {
+			token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		switch r.URL.Path {
+		case "/auth.test":
+			_, _ = w.Write([]byte(`{"ok":true,"user_id":"U-` + token + `"}`))
+		case "/chat.postMessage":
+			posts <- token + ": " + r.Form.Get("text")
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens := NewTokenStore(&MemoryStore{})
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxb-1"}))
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T2", BotToken: "xoxb-2"}))
+
+	var h testutils.FakeHandler
+	b := newBotWith(slack.New("", slack.OptionAppLevelToken("xapp-1")), &h,
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithClientProvider(NewTokenStoreClientProvider(tokens, slack.OptionAPIURL(s.URL+"/"))),
+		WithCommand("foo", HandlerFunc(func(ctx context.Context, _ ...string) []slack.MsgOption {
+			req, _ := RequestFromContext(ctx)
+			return []slack.MsgOption{slack.MsgOptionText("foo from "+req.TeamID, false)}
+		})),
+	)
+	errCh := make(chan error)
+	go func() { errCh <- b.Run(ctx) }()
+
+	slackClient := slack.New("", slack.OptionHTTPClient(&http.Client{Transport: &testutils.StubbedRoundTripper{}}))
+	smClient := socketmode.New(slackClient)
+	for _, team := range []string{"T1", "T2"} {
+		go h.SendEvent(&socketmode.Event{
+			Request: &socketmode.Request{},
+			Data: slackevents.EventsAPIEvent{
+				TeamID: team,
+				InnerEvent: slackevents.EventsAPIInnerEvent{
+					Type: string(slackevents.Message),
+					Data: &slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "foo"},
+				},
+			},
+		}, smClient)
+		token := "xoxb-" + strings.TrimPrefix(team, "T")
+		assert.Equal(t, token+": foo from "+team, <-posts)
+	}
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
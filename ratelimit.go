@@ -0,0 +1,168 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Limit is a token bucket: it allows Burst commands at once, and one more command every Interval after that.
+// The zero Limit doesn't limit commands.
+type Limit struct {
+	Burst    int
+	Interval time.Duration
+}
+
+func (l Limit) enabled() bool {
+	return l.Burst > 0 && l.Interval > 0
+}
+
+// A RateLimit limits how often a command can be executed. Each command has its own limits: a user spamming one
+// command can still execute other commands.
+type RateLimit struct {
+	// PerUser limits how often each user can execute the command.
+	PerUser Limit
+	// PerChannel limits how often the command can be executed in each channel.
+	PerChannel Limit
+	// PerCommand limits how often the command can be executed, by all users.
+	PerCommand Limit
+	// Exempt holds the IDs of the users (e.g. admins) that are not rate limited.
+	Exempt []string
+}
+
+var _ Handler = &rateLimiter{}
+
+// RateLimited returns a Handler that executes the handler, unless the user exceeds the rate limit. In that case,
+// the user is told when to try again. If the handler is a Commands, each of its commands is limited separately.
+//
+// Commands issued without a user (e.g. scheduled jobs) are not rate limited.
+func RateLimited(handler Handler, limit RateLimit) Handler {
+	return newRateLimiter(handler, limit)
+}
+
+type rateLimiter struct {
+	handler Handler
+	limit   RateLimit
+	buckets map[bucketKey]*bucket
+	calls   int
+	lock    sync.Mutex
+}
+
+type bucketKey struct {
+	scope   string
+	id      string
+	command string
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter(handler Handler, limit RateLimit) *rateLimiter {
+	return &rateLimiter{handler: handler, limit: limit, buckets: make(map[bucketKey]*bucket)}
+}
+
+func (r *rateLimiter) Handle(ctx context.Context, args ...string) []slack.MsgOption {
+	req, _ := RequestFromContext(ctx)
+	if req.UserID == "" || slices.Contains(r.limit.Exempt, req.UserID) {
+		return r.handler.Handle(ctx, args...)
+	}
+	command := CommandPath(ctx)
+	if commands, ok := r.handler.(Commands); ok {
+		handler, path, _ := commands.resolve(ctx, args...)
+		if handler == nil {
+			// invalid commands aren't limited: the handler only lists the supported commands
+			return r.handler.Handle(ctx, args...)
+		}
+		command = append(command, path...)
+	}
+	if wait := r.reserve(req, strings.Join(command, " "), time.Now()); wait > 0 {
+		seconds := time.Duration(math.Ceil(wait.Seconds())) * time.Second
+		output := errorMessage(ctx, "too many requests", Translate(ctx, "try again in %s", seconds))
+		if Respond(ctx, Ephemeral(output...)) {
+			return nil
+		}
+		return output
+	}
+	return r.handler.Handle(ctx, args...)
+}
+
+// Unwrap returns the rate limited Handler.
+func (r *rateLimiter) Unwrap() Handler {
+	return r.handler
+}
+
+// maxBuckets is the number of buckets above which the rateLimiter removes the buckets that are full again.
+const maxBuckets = 1000
+
+// reserve takes a token from each of the request's buckets for the command. If any bucket is empty, it takes no tokens
+// and returns how long to wait until all buckets have a token.
+func (r *rateLimiter) reserve(req Request, command string, now time.Time) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	limits := []struct {
+		key   bucketKey
+		limit Limit
+	}{
+		{key: bucketKey{scope: "user", id: req.TeamID + "/" + req.UserID, command: command}, limit: r.limit.PerUser},
+		{key: bucketKey{scope: "channel", id: req.TeamID + "/" + req.ChannelID, command: command}, limit: r.limit.PerChannel},
+		{key: bucketKey{scope: "command", id: req.TeamID, command: command}, limit: r.limit.PerCommand},
+	}
+	var wait time.Duration
+	var buckets []*bucket
+	for _, l := range limits {
+		if !l.limit.enabled() {
+			continue
+		}
+		b, ok := r.buckets[l.key]
+		if !ok {
+			b = &bucket{tokens: float64(l.limit.Burst), updated: now}
+			r.buckets[l.key] = b
+		}
+		b.refill(l.limit, now)
+		if b.tokens < 1 {
+			wait = max(wait, time.Duration((1-b.tokens)*float64(l.limit.Interval)))
+		}
+		buckets = append(buckets, b)
+	}
+	if wait > 0 {
+		return wait
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	if r.calls++; len(r.buckets) > maxBuckets && r.calls%maxBuckets == 0 {
+		r.prune(now)
+	}
+	return 0
+}
+
+// prune removes the buckets that are full again: they behave the same as a new bucket.
+func (r *rateLimiter) prune(now time.Time) {
+	for key, b := range r.buckets {
+		var limit Limit
+		switch key.scope {
+		case "user":
+			limit = r.limit.PerUser
+		case "channel":
+			limit = r.limit.PerChannel
+		default:
+			limit = r.limit.PerCommand
+		}
+		if b.refill(limit, now); b.tokens >= float64(limit.Burst) {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+// refill adds the tokens accumulated since the bucket was last updated, up to the limit's burst.
+func (b *bucket) refill(limit Limit, now time.Time) {
+	b.tokens = min(float64(limit.Burst), b.tokens+float64(now.Sub(b.updated))/float64(limit.Interval))
+	b.updated = now
+}
@@ -0,0 +1,132 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiter_reserve(t *testing.T) {
+	r := newRateLimiter(nil, RateLimit{
+		PerUser:    Limit{Burst: 2, Interval: time.Minute},
+		PerChannel: Limit{Burst: 3, Interval: time.Minute},
+	})
+	now := time.Now()
+	u1 := Request{ChannelID: "C1", UserID: "U1"}
+	u2 := Request{ChannelID: "C1", UserID: "U2"}
+
+	// the user's burst
+	assert.Zero(t, r.reserve(u1, "report", now))
+	assert.Zero(t, r.reserve(u1, "report", now))
+	assert.Equal(t, time.Minute, r.reserve(u1, "report", now))
+	// other commands have their own limits
+	assert.Zero(t, r.reserve(u1, "status", now))
+	// the channel's burst
+	assert.Zero(t, r.reserve(u2, "report", now))
+	assert.Equal(t, time.Minute, r.reserve(u2, "report", now))
+	// a rejected command doesn't take a token: the channel refills in 30s
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 30*time.Second, r.reserve(u1, "report", now))
+	now = now.Add(30 * time.Second)
+	assert.Zero(t, r.reserve(u2, "report", now))
+	assert.Equal(t, time.Minute, r.reserve(u1, "report", now))
+}
+
+func TestBot_RateLimit(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+	})
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithRateLimit(RateLimit{PerUser: Limit{Burst: 1, Interval: time.Minute}, Exempt: []string{"U2"}}),
+		WithCommand("echo", echo),
+		WithCommand("nested", Commands{"echo": RateLimited(echo, RateLimit{PerCommand: Limit{Burst: 1, Interval: time.Hour}})}),
+	)
+
+	tests := []struct {
+		name     string
+		userID   string
+		input    string
+		wantText string
+		wantUser string
+	}{
+		{name: "allowed", userID: "U1", input: "echo foo", wantText: "foo"},
+		{name: "limited", userID: "U1", input: "echo foo", wantUser: "U1"},
+		{name: "exempt", userID: "U2", input: "echo foo", wantText: "foo"},
+		{name: "exempt", userID: "U2", input: "echo foo", wantText: "foo"},
+		{name: "nested", userID: "U1", input: "nested echo bar", wantText: "bar"},
+		{name: "nested limited", userID: "U2", input: "nested echo bar", wantUser: "U2"},
+	}
+	for _, tt := range tests {
+		req := Request{ChannelID: "C1", UserID: tt.userID}
+		require.NoError(t, b.handle(context.Background(), req, "<@W23456789> "+tt.input), tt.name)
+		post := <-ts.post
+		assert.Equal(t, tt.wantText, post.Get("text"), tt.name)
+		assert.Equal(t, tt.wantUser, post.Get("user"), tt.name)
+		if tt.wantUser != "" {
+			assert.Contains(t, post.Get("attachments"), `"title":"too many requests"`, tt.name)
+		}
+	}
+	assert.Empty(t, ts.post)
+
+	require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1", UserID: "U3"}, "<@W23456789> nested echo bar"))
+	assert.Contains(t, (<-ts.post).Get("attachments"), `"text":"try again in 1h0m0s"`)
+}
+
+func TestBot_RateLimit_Fallback(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText("echo "+strings.Join(args, " "), false)}
+	})
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithRateLimit(RateLimit{PerUser: Limit{Burst: 1, Interval: time.Minute}}),
+		WithFallback(Intents{Keyword("status", "status"), Keyword("hello", "echo", "hello")}),
+		WithCommand("status", HandlerFunc(func(_ context.Context, _ ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("all good", false)}
+		})),
+		WithCommand("echo", echo),
+	)
+
+	tests := []struct {
+		name     string
+		input    string
+		wantText string
+		limited  bool
+	}{
+		{name: "fallback", input: "what's the status?", wantText: "all good"},
+		{name: "fallback to another command", input: "hello there", wantText: "echo hello"},
+		{name: "fallback limited", input: "status please", limited: true},
+		{name: "resolved command limited", input: "status", limited: true},
+		{name: "invalid", input: "foo"},
+		{name: "invalid not limited", input: "foo"},
+	}
+	for _, tt := range tests {
+		require.NoError(t, b.handle(context.Background(), Request{ChannelID: "C1", UserID: "U1"}, "<@W23456789> "+tt.input), tt.name)
+		post := <-ts.post
+		switch {
+		case tt.limited:
+			assert.Contains(t, post.Get("attachments"), `"title":"too many requests"`, tt.name)
+		case tt.wantText != "":
+			assert.Equal(t, tt.wantText, post.Get("text"), tt.name)
+		default:
+			assert.Contains(t, post.Get("attachments"), `"title":"invalid command"`, tt.name)
+		}
+	}
+	assert.Empty(t, ts.post)
+}
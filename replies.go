@@ -0,0 +1,282 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"github.com/slack-go/slack/slackevents"
+	"slices"
+	"sync"
+)
+
+const defaultRepliesSize = 500
+
+// replies remembers the messages the Bot posted in reply to a command, so the Bot can update them when the command's
+// message is edited, or delete them when it's deleted. Only the most recent commands are remembered.
+type replies struct {
+	sent  map[replyKey]reply
+	order []replyKey
+	size  int
+	// deleted holds the messages the Bot deleted itself (see Sensitive), so it doesn't delete their replies.
+	deleted []replyKey
+	lock    sync.Mutex
+}
+
+type replyKey struct {
+	teamID    string
+	channelID string
+	ts        string
+}
+
+// A reply holds the user that issued a command and the timestamps of the messages the Bot posted in reply.
+type reply struct {
+	userID string
+	sent   []string
+	// heard is true if the Bot replied to the message with its Listeners, rather than by executing it as a command.
+	heard bool
+}
+
+// get returns the Bot's reply to the request's message.
+func (r *replies) get(req Request) (reply, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	entry, ok := r.sent[replyKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS}]
+	return entry, ok
+}
+
+// set records the Bot's reply to the request's message.
+func (r *replies) set(req Request, entry reply) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.sent == nil {
+		r.sent = make(map[replyKey]reply)
+	}
+	key := replyKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS}
+	if _, ok := r.sent[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.sent[key] = entry
+	for len(r.order) > r.size {
+		delete(r.sent, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// remove forgets the request's message and returns the timestamps of the messages posted in reply.
+func (r *replies) remove(req Request) ([]string, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := replyKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS}
+	entry, ok := r.sent[key]
+	if ok {
+		delete(r.sent, key)
+		r.order = slices.DeleteFunc(r.order, func(k replyKey) bool { return k == key })
+	}
+	return entry.sent, ok
+}
+
+// deleting records that the Bot deletes the request's message itself.
+func (r *replies) deleting(req Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.deleted = append(r.deleted, replyKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS})
+	if len(r.deleted) > r.size {
+		r.deleted = r.deleted[1:]
+	}
+}
+
+// deletedByBot returns true if the Bot deleted the request's message itself, and forgets the deletion.
+func (r *replies) deletedByBot(req Request) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := replyKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS}
+	i := slices.Index(r.deleted, key)
+	if i >= 0 {
+		r.deleted = slices.Delete(r.deleted, i, i+1)
+	}
+	return i >= 0
+}
+
+// removeReply forgets the reply with the timestamp. It returns false if the Bot didn't post the reply in response to
+// a command issued by the user.
+func (r *replies) removeReply(teamID, channelID, userID, ts string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for key, entry := range r.sent {
+		if key.teamID != teamID || key.channelID != channelID || entry.userID != userID || !slices.Contains(entry.sent, ts) {
+			continue
+		}
+		entry.sent = slices.DeleteFunc(slices.Clone(entry.sent), func(s string) bool { return s == ts })
+		r.sent[key] = entry
+		return true
+	}
+	return false
+}
+
+// A replyTracker records the messages posted in the command's channel while the Bot executes a command. When the Bot
+// re-executes an edited command, the tracker hands out the previous replies, so the new output replaces them.
+//
+// If the command's message holds a Sensitive command that deletes it, the tracker is private: the Bot posts all its
+// replies to the message as ephemeral responses, and doesn't remember them.
+type replyTracker struct {
+	channelID string
+	previous  []string
+	sent      []string
+	private   bool
+	lock      sync.Mutex
+}
+
+type replyTrackerKey struct{}
+
+func withReplyTracker(ctx context.Context, t *replyTracker) context.Context {
+	return context.WithValue(ctx, replyTrackerKey{}, t)
+}
+
+// replyTrackerFrom returns the context's replyTracker, or nil if the Bot isn't tracking the replies of a command.
+func replyTrackerFrom(ctx context.Context) *replyTracker {
+	t, _ := ctx.Value(replyTrackerKey{}).(*replyTracker)
+	return t
+}
+
+// reuse returns the next previous reply to update with a message for the channel, if any.
+func (t *replyTracker) reuse(channelID string) (string, bool) {
+	if t == nil || channelID != t.channelID {
+		return "", false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.previous) == 0 {
+		return "", false
+	}
+	ts := t.previous[0]
+	t.previous = t.previous[1:]
+	return ts, true
+}
+
+// makePrivate makes the tracker private.
+func (t *replyTracker) makePrivate() {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.private = true
+}
+
+// isPrivate returns true if the Bot must post its replies as ephemeral responses.
+func (t *replyTracker) isPrivate() bool {
+	if t == nil {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.private
+}
+
+// add records a message posted in the channel.
+func (t *replyTracker) add(channelID, ts string) {
+	if t == nil || channelID != t.channelID || ts == "" {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.sent = append(t.sent, ts)
+}
+
+// tracked handles the command in the request, recording the messages the Bot posts in reply.
+func (b *Bot) tracked(ctx context.Context, req Request, f func(context.Context) error) error {
+	if req.TS == "" {
+		return f(ctx)
+	}
+	t := replyTracker{channelID: req.ChannelID}
+	previous, _ := b.replies.get(req)
+	t.previous = previous.sent
+	err := f(withReplyTracker(ctx, &t))
+	if t.isPrivate() {
+		b.replies.remove(req)
+	} else {
+		b.replies.set(req, reply{userID: req.UserID, sent: slices.Clone(t.sent)})
+	}
+	// delete any previous replies the new output didn't replace
+	if len(t.previous) > 0 {
+		client, cErr := b.client(ctx, req.TeamID)
+		if cErr != nil {
+			return errors.Join(err, cErr)
+		}
+		for _, ts := range t.previous {
+			if _, _, dErr := client.DeleteMessageContext(ctx, req.ChannelID, ts); dErr != nil {
+				err = errors.Join(err, dErr)
+			}
+		}
+	}
+	return err
+}
+
+// onEdit re-executes a command when the user edits its message, updating the Bot's previous replies with the new output.
+// Messages the Bot didn't reply to (or replied to with its Listeners), and edits that don't change the message's text
+// (e.g. when Slack adds a link preview), are ignored.
+func (b *Bot) onEdit(ctx context.Context, teamID string, data *slackevents.MessageEvent) {
+	if data.Message == nil || data.Message.BotID != "" {
+		return
+	}
+	if data.PreviousMessage != nil && data.PreviousMessage.Text == data.Message.Text {
+		return
+	}
+	req := Request{TeamID: teamID, ChannelID: data.Channel, UserID: data.Message.User, TS: data.Message.TimeStamp, ThreadTS: data.Message.ThreadTimeStamp}
+	if req.ThreadTS == req.TS {
+		req.ThreadTS = ""
+	}
+	if previous, ok := b.replies.get(req); !ok || previous.heard {
+		return
+	}
+	b.logger.Debug("re-executing edited command", "channel", req.ChannelID, "ts", req.TS)
+	if err := b.handle(ctx, req, data.Message.Text); err != nil {
+		b.logger.Warn("failed to re-execute edited command", "channel", req.ChannelID, "err", err)
+	}
+}
+
+// onDelete deletes the Bot's replies to a command when the user deletes the command's message. See WithReplyDeletion.
+// Messages the Bot deleted itself, because they held a Sensitive command, keep their replies.
+func (b *Bot) onDelete(ctx context.Context, teamID string, data *slackevents.MessageEvent) {
+	req := Request{TeamID: teamID, ChannelID: data.Channel, TS: data.DeletedTimeStamp}
+	if req.TS == "" && data.PreviousMessage != nil {
+		req.TS = data.PreviousMessage.TimeStamp
+	}
+	if !b.deleteReplies || req.TS == "" || b.replies.deletedByBot(req) {
+		return
+	}
+	sent, ok := b.replies.remove(req)
+	if !ok || len(sent) == 0 {
+		return
+	}
+	client, err := b.client(ctx, teamID)
+	if err != nil {
+		b.logger.Warn("failed to delete replies", "channel", req.ChannelID, "err", err)
+		return
+	}
+	b.logger.Debug("deleting replies to deleted command", "channel", req.ChannelID, "ts", req.TS)
+	for _, ts := range sent {
+		if _, _, err = client.DeleteMessageContext(ctx, req.ChannelID, ts); err != nil {
+			b.logger.Warn("failed to delete reply", "channel", req.ChannelID, "ts", ts, "err", err)
+		}
+	}
+}
+
+// deleteReaction is the reaction that lets the user that issued a command delete the Bot's reply.
+const deleteReaction = "x"
+
+// onReaction deletes a reply when the user that issued the command reacts to it with :x:. See WithReplyDeletion.
+func (b *Bot) onReaction(ctx context.Context, teamID string, data *slackevents.ReactionAddedEvent) {
+	if !b.deleteReplies || data.Reaction != deleteReaction || data.Item.Type != "message" {
+		return
+	}
+	if !b.replies.removeReply(teamID, data.Item.Channel, data.User, data.Item.Timestamp) {
+		return
+	}
+	client, err := b.client(ctx, teamID)
+	if err == nil {
+		_, _, err = client.DeleteMessageContext(ctx, data.Item.Channel, data.Item.Timestamp)
+	}
+	if err != nil {
+		b.logger.Warn("failed to delete reply", "channel", data.Item.Channel, "ts", data.Item.Timestamp, "err", err)
+	}
+}
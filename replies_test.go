@@ -0,0 +1,215 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestReplies(t *testing.T) {
+	r := replies{size: 2}
+	for _, ts := range []string{"1.0", "2.0", "1.0", "3.0"} {
+		r.set(Request{ChannelID: "C1", TS: ts}, reply{sent: []string{"10" + ts}})
+	}
+	_, ok := r.get(Request{ChannelID: "C1", TS: "1.0"})
+	assert.False(t, ok)
+	entry, ok := r.get(Request{ChannelID: "C1", TS: "2.0"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"102.0"}, entry.sent)
+	_, ok = r.get(Request{ChannelID: "C2", TS: "2.0"})
+	assert.False(t, ok)
+}
+
+func TestBot_onEdit(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), updates: make(chan url.Values, 10), deletes: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("echo", HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+		})),
+		WithCommand("whisper", HandlerFunc(func(ctx context.Context, args ...string) []slack.MsgOption {
+			Respond(ctx, Ephemeral(slack.MsgOptionText(strings.Join(args, " "), false)))
+			return nil
+		})),
+	)
+	ctx := context.Background()
+	edit := func(ts, previous, text string) *slackevents.MessageEvent {
+		return &slackevents.MessageEvent{
+			Channel:         "C1",
+			SubType:         "message_changed",
+			Message:         &slackevents.MessageEvent{User: "U1", Text: text, TimeStamp: ts, ThreadTimeStamp: ts},
+			PreviousMessage: &slackevents.MessageEvent{User: "U1", Text: previous, TimeStamp: ts},
+		}
+	}
+
+	require.NoError(t, b.handle(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}, "<@W23456789> echo foo"))
+	assert.Equal(t, "foo", (<-ts.post).Get("text"))
+
+	// the edit updates the previous reply
+	b.onMessage(ctx, "", edit("1.0", "<@W23456789> echo foo", "<@W23456789> echo bar"))
+	update := <-ts.updates
+	assert.Equal(t, "C1", update.Get("channel"))
+	assert.Equal(t, "100.0", update.Get("ts"))
+	assert.Equal(t, "bar", update.Get("text"))
+
+	// edits that don't change the text, and edits of messages the bot didn't reply to, are ignored
+	b.onMessage(ctx, "", edit("1.0", "<@W23456789> echo bar", "<@W23456789> echo bar"))
+	b.onMessage(ctx, "", edit("2.0", "<@W23456789> echo foo", "<@W23456789> echo bar"))
+	assert.Empty(t, ts.post)
+	assert.Empty(t, ts.updates)
+
+	// previous replies that aren't replaced are deleted
+	b.onMessage(ctx, "", edit("1.0", "<@W23456789> echo bar", "<@W23456789> whisper bar"))
+	post := <-ts.post
+	assert.Equal(t, "U1", post.Get("user"))
+	assert.Equal(t, "bar", post.Get("text"))
+	deleted := <-ts.deletes
+	assert.Equal(t, "C1", deleted.Get("channel"))
+	assert.Equal(t, "100.0", deleted.Get("ts"))
+
+	// with no previous replies left, the next edit posts a new reply
+	b.onMessage(ctx, "", edit("1.0", "<@W23456789> whisper bar", "<@W23456789> echo snafu"))
+	post = <-ts.post
+	assert.Equal(t, "snafu", post.Get("text"))
+	assert.Empty(t, post.Get("user"))
+	assert.Empty(t, ts.updates)
+	assert.Empty(t, ts.deletes)
+}
+
+func TestBot_ReplyDeletion(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), deletes: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithReplyDeletion(),
+		WithCommand("echo", HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+		})),
+	)
+	ctx := context.Background()
+	reaction := func(user, reaction string) *slackevents.ReactionAddedEvent {
+		return &slackevents.ReactionAddedEvent{User: user, Reaction: reaction, Item: slackevents.Item{Type: "message", Channel: "C1", Timestamp: "100.0"}}
+	}
+
+	// the user that issued the command can delete the reply with :x:
+	require.NoError(t, b.handle(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}, "<@W23456789> echo foo"))
+	<-ts.post
+	b.onReaction(ctx, "", reaction("U2", "x"))
+	b.onReaction(ctx, "", reaction("U1", "thumbsup"))
+	assert.Empty(t, ts.deletes)
+	b.onReaction(ctx, "", reaction("U1", "x"))
+	assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {"100.0"}, "token": {"x0xb-foo"}}, <-ts.deletes)
+	b.onReaction(ctx, "", reaction("U1", "x"))
+	assert.Empty(t, ts.deletes)
+
+	// deleting the command deletes the reply
+	require.NoError(t, b.handle(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "2.0"}, "<@W23456789> echo foo"))
+	<-ts.post
+	deleted := &slackevents.MessageEvent{Channel: "C1", SubType: "message_deleted", DeletedTimeStamp: "2.0"}
+	b.onMessage(ctx, "", deleted)
+	assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {"100.0"}, "token": {"x0xb-foo"}}, <-ts.deletes)
+	b.onMessage(ctx, "", deleted)
+	assert.Empty(t, ts.deletes)
+
+	// without WithReplyDeletion, replies aren't deleted
+	b.deleteReplies = false
+	require.NoError(t, b.handle(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "3.0"}, "<@W23456789> echo foo"))
+	<-ts.post
+	b.onReaction(ctx, "", reaction("U1", "x"))
+	b.onMessage(ctx, "", &slackevents.MessageEvent{Channel: "C1", SubType: "message_deleted", DeletedTimeStamp: "3.0"})
+	assert.Empty(t, ts.deletes)
+}
+
+func TestBot_ReplyDeletion_Sensitive(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), deletes: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+	})
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithUserToken("xoxp-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithReplyDeletion(),
+		WithBatchCommands(BatchThreaded),
+		WithCommand("token", Sensitive{Handler: echo, DeleteMessage: true}),
+		WithCommand("echo", echo),
+	)
+	ctx := context.Background()
+
+	// the Bot deletes the message with the sensitive command: this doesn't delete the other commands' replies
+	require.NoError(t, b.handle(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}, "<@W23456789> token secret ; echo foo"))
+	assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {"1.0"}, "token": {"xoxp-foo"}}, <-ts.deletes)
+	for range 2 {
+		<-ts.post
+	}
+	b.onMessage(ctx, "", &slackevents.MessageEvent{Channel: "C1", SubType: "message_deleted", DeletedTimeStamp: "1.0"})
+	assert.Empty(t, ts.deletes)
+}
+
+func TestBot_ReplyDeletion_Listener(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), updates: make(chan url.Values, 10), deletes: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithReplyDeletion(),
+		WithListener(Listener{Pattern: regexp.MustCompile(`\b[A-Z]+-\d+\b`), Handler: HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+		})}),
+	)
+	ctx := context.Background()
+	message := func(ts string) *slackevents.MessageEvent {
+		return &slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "see JIRA-1", TimeStamp: ts}
+	}
+	reaction := func(user string) *slackevents.ReactionAddedEvent {
+		return &slackevents.ReactionAddedEvent{User: user, Reaction: "x", Item: slackevents.Item{Type: "message", Channel: "C1", Timestamp: "100.0"}}
+	}
+
+	// the user that posted the message can delete the listener's reply with :x:
+	b.onMessage(ctx, "", message("1.0"))
+	assert.Equal(t, "JIRA-1", (<-ts.post).Get("text"))
+	b.onReaction(ctx, "", reaction("U2"))
+	assert.Empty(t, ts.deletes)
+	b.onReaction(ctx, "", reaction("U1"))
+	assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {"100.0"}, "token": {"x0xb-foo"}}, <-ts.deletes)
+
+	// deleting the message deletes the listener's reply
+	b.onMessage(ctx, "", message("2.0"))
+	<-ts.post
+	b.onMessage(ctx, "", &slackevents.MessageEvent{Channel: "C1", SubType: "message_deleted", DeletedTimeStamp: "2.0"})
+	assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {"100.0"}, "token": {"x0xb-foo"}}, <-ts.deletes)
+
+	// editing the message doesn't execute it as a command
+	b.onMessage(ctx, "", message("3.0"))
+	<-ts.post
+	b.onMessage(ctx, "", &slackevents.MessageEvent{
+		Channel:         "C1",
+		SubType:         "message_changed",
+		Message:         &slackevents.MessageEvent{User: "U1", Text: "see JIRA-2", TimeStamp: "3.0"},
+		PreviousMessage: &slackevents.MessageEvent{User: "U1", Text: "see JIRA-1", TimeStamp: "3.0"},
+	})
+	assert.Empty(t, ts.post)
+	assert.Empty(t, ts.updates)
+
+	// messages the listeners didn't reply to aren't remembered
+	b.onMessage(ctx, "", &slackevents.MessageEvent{Channel: "C1", ChannelType: "channel", User: "U1", Text: "hello", TimeStamp: "4.0"})
+	_, ok := b.replies.get(Request{ChannelID: "C1", TS: "4.0"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,32 @@
+package slackapp
+
+import (
+	"context"
+)
+
+// A Request describes where, and by whom, a command was issued. The Bot adds it to the context passed to the Handler.
+type Request struct {
+	// TeamID is the workspace where the command was issued.
+	TeamID string
+	// ChannelID is the channel where the command was issued.
+	ChannelID string
+	// UserID is the user that issued the command.
+	UserID string
+	// TS is the timestamp of the message containing the command.
+	TS string
+	// ThreadTS is the timestamp of the thread's parent message, if the command was issued in a thread.
+	ThreadTS string
+}
+
+type requestKey struct{}
+
+func withRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFromContext returns the Request for the command being executed. It returns false if the command wasn't issued
+// through a Bot.
+func RequestFromContext(ctx context.Context) (Request, bool) {
+	req, ok := ctx.Value(requestKey{}).(Request)
+	return req, ok
+}
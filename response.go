@@ -0,0 +1,322 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"slices"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Target determines where a Response is posted.
+type Target int
+
+const (
+	// TargetChannel posts the response in the channel where the command was issued.
+	TargetChannel Target = iota
+	// TargetEphemeral posts the response in the channel where the command was issued, visible only to the user that issued it.
+	TargetEphemeral
+	// TargetDirectMessage sends the response as a direct message to the user that issued the command.
+	TargetDirectMessage
+	// TargetOtherChannel posts the response in the Response's Channel.
+	TargetOtherChannel
+)
+
+// A Response is a message posted by the Bot in response to a command.
+//
+// By default, the Bot posts the output of a Handler in the channel where the command was issued. Handlers that want to direct
+// their output elsewhere, or that want to post several messages (e.g. a public summary and the details only visible to the user),
+// use Respond to add Responses.
+//
+// A Response may contain Files, which the Bot uploads after posting the message, in the same channel (and thread).
+type Response struct {
+	Target  Target
+	Channel string
+	Options []slack.MsgOption
+	Files   []File
+}
+
+// WithFiles returns a copy of the Response with the files added.
+func (r Response) WithFiles(files ...File) Response {
+	r.Files = append(slices.Clone(r.Files), files...)
+	return r
+}
+
+// Public returns a Response that is posted in the channel where the command was issued.
+func Public(options ...slack.MsgOption) Response {
+	return Response{Target: TargetChannel, Options: options}
+}
+
+// Ephemeral returns a Response that is only visible to the user that issued the command.
+func Ephemeral(options ...slack.MsgOption) Response {
+	return Response{Target: TargetEphemeral, Options: options}
+}
+
+// DirectMessage returns a Response that is sent as a direct message to the user that issued the command.
+func DirectMessage(options ...slack.MsgOption) Response {
+	return Response{Target: TargetDirectMessage, Options: options}
+}
+
+// InChannel returns a Response that is posted in the provided channel.
+func InChannel(channel string, options ...slack.MsgOption) Response {
+	return Response{Target: TargetOtherChannel, Channel: channel, Options: options}
+}
+
+type responses struct {
+	responses []Response
+	failed    bool
+	reason    string
+	threadTS  string
+	lock      sync.Mutex
+}
+
+type responsesKey struct{}
+
+func withResponses(ctx context.Context) (context.Context, *responses) {
+	var r responses
+	return context.WithValue(ctx, responsesKey{}, &r), &r
+}
+
+// Respond adds responses to the command being executed. The Bot posts them after the Handler returns, after the Handler's own output.
+// Respond returns false if the command wasn't issued through a Bot.
+func Respond(ctx context.Context, response ...Response) bool {
+	r, ok := ctx.Value(responsesKey{}).(*responses)
+	if ok {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.responses = append(r.responses, response...)
+	}
+	return ok
+}
+
+// Fail marks the command being executed as failed. When a message contains several commands (e.g. "foo && bar"), the Bot
+// doesn't execute the commands that depend on a failed command. Fail returns false if the command wasn't issued through a Bot.
+func Fail(ctx context.Context) bool {
+	return fail(ctx, "")
+}
+
+// fail marks the command being executed as failed, for the reason.
+func fail(ctx context.Context, reason string) bool {
+	r, ok := ctx.Value(responsesKey{}).(*responses)
+	if ok {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.failed = true
+		if r.reason == "" {
+			r.reason = reason
+		}
+	}
+	return ok
+}
+
+// errorMessage marks the command as failed and returns an error message with the (translated) title and text.
+func errorMessage(ctx context.Context, title, text string) []slack.MsgOption {
+	fail(ctx, title+": "+text)
+	return []slack.MsgOption{slack.MsgOptionAttachments(slack.Attachment{
+		Color: "bad",
+		Title: Translate(ctx, title),
+		Text:  text,
+	})}
+}
+
+// failure returns true if the command failed, and the reason why.
+func (r *responses) failure() (bool, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.failed, r.reason
+}
+
+func (r *responses) get() []Response {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.responses
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var (
+	errNoUser        = errors.New("no user to respond to")
+	errEphemeralFile = errors.New("files can't be posted as ephemeral responses")
+)
+
+// run executes the command and posts its output: the messages returned by f, followed by any Responses added by Respond.
+func (b *Bot) run(ctx context.Context, req Request, f func(context.Context) []slack.MsgOption) error {
+	output, _ := b.execute(ctx, req, f)
+	return b.postAll(ctx, req, output)
+}
+
+// execute executes the command and returns its output: the messages returned by f, followed by any Responses added by
+// Respond. It returns false if the command failed (see Fail).
+func (b *Bot) execute(ctx context.Context, req Request, f func(context.Context) []slack.MsgOption) ([]Response, bool) {
+	ctx, r := withResponses(b.commandContext(ctx, req))
+	var output []Response
+	if options := f(ctx); len(options) > 0 {
+		output = append(output, Public(options...))
+	}
+	output = append(output, r.get()...)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	// a session was started: post the output in the session's thread
+	return inThread(output, r.threadTS), !r.failed
+}
+
+// commandContext returns the context in which the Bot executes the request's commands.
+func (b *Bot) commandContext(ctx context.Context, req Request) context.Context {
+	ctx = b.withLocale(ctx, req.TeamID, req.UserID)
+	ctx = withMatching(ctx, b.matching)
+	ctx = withResolver(ctx, b.fallback)
+	ctx = withRequest(ctx, req)
+	ctx = withStore(ctx, b.store)
+	return withSessions(ctx, &b.sessions)
+}
+
+func (b *Bot) postAll(ctx context.Context, req Request, output []Response) error {
+	var errs error
+	for _, response := range output {
+		if err := b.post(ctx, req, response); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+func (b *Bot) post(ctx context.Context, req Request, response Response) error {
+	if response.Target == TargetChannel && replyTrackerFrom(ctx).isPrivate() {
+		response.Target = TargetEphemeral
+	}
+	client, err := b.client(ctx, req.TeamID)
+	if err != nil {
+		return err
+	}
+	var channel string
+	switch response.Target {
+	case TargetChannel:
+		channel = req.ChannelID
+	case TargetEphemeral:
+		if req.UserID == "" {
+			return errNoUser
+		}
+		if len(response.Files) > 0 {
+			return errEphemeralFile
+		}
+		return b.postEphemeral(ctx, client, req.ChannelID, req.UserID, response.Options)
+	case TargetDirectMessage:
+		if req.UserID == "" {
+			return errNoUser
+		}
+		conversation, _, _, err := client.OpenConversationContext(ctx, &slack.OpenConversationParameters{Users: []string{req.UserID}})
+		if err != nil {
+			return err
+		}
+		channel = conversation.ID
+	case TargetOtherChannel:
+		channel = response.Channel
+	default:
+		return fmt.Errorf("invalid target: %d", response.Target)
+	}
+	if len(response.Files) == 0 {
+		return b.postMessage(ctx, client, channel, response.Options)
+	}
+	// upload the files in the message's thread. If the message has no content (i.e. only files), don't post it.
+	m, err := parseMessage(response.Options...)
+	if err != nil {
+		return err
+	}
+	if !m.empty() {
+		if err = b.postMessage(ctx, client, channel, response.Options); err != nil {
+			return err
+		}
+	}
+	for _, file := range response.Files {
+		if err = uploadFile(ctx, client, channel, m.threadTS, file); err != nil {
+			return fmt.Errorf("upload %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// postMessage posts the message in the channel. If the message exceeds Slack's limits, it's split into several messages,
+// or its text is uploaded as a file, depending on the Bot's OversizePolicy. Split messages are posted as replies in the
+// thread of the first message.
+//
+// When the Bot re-executes an edited command, the message replaces one of the command's previous replies instead.
+func (b *Bot) postMessage(ctx context.Context, client *slack.Client, channel string, options []slack.MsgOption) error {
+	tracker := replyTrackerFrom(ctx)
+	m, err := parseMessage(options...)
+	if err != nil || !m.oversized() {
+		if ts, ok := tracker.reuse(channel); ok {
+			if _, _, _, err = client.UpdateMessageContext(ctx, channel, ts, options...); err == nil {
+				tracker.add(channel, ts)
+				return nil
+			}
+			b.logger.Debug("failed to update reply. posting a new one", "channel", channel, "ts", ts, "err", err)
+		}
+		_, ts, err := client.PostMessageContext(ctx, channel, options...)
+		tracker.add(channel, ts)
+		return err
+	}
+	b.logger.Debug("message exceeds Slack's limits", "channel", channel, "policy", b.oversize)
+	if b.oversize == OversizeUpload && utf8.RuneCountInString(m.text) > maxTextLength {
+		content, _ := codeBlockContent(m.text)
+		if err = uploadFile(ctx, client, channel, m.threadTS, File{Name: "output.txt", Content: []byte(content)}); err != nil {
+			return err
+		}
+		if m.text = ""; m.empty() {
+			return nil
+		}
+	}
+	threadTS := m.threadTS
+	for _, part := range m.split() {
+		part.threadTS = threadTS
+		_, ts, err := client.PostMessageContext(ctx, channel, part.options()...)
+		if err != nil {
+			return err
+		}
+		tracker.add(channel, ts)
+		if threadTS == "" {
+			threadTS = ts
+		}
+	}
+	return nil
+}
+
+// postEphemeral posts the ephemeral message in the channel. If the message exceeds Slack's limits, it's split into
+// several messages.
+func (b *Bot) postEphemeral(ctx context.Context, client *slack.Client, channel string, userID string, options []slack.MsgOption) error {
+	m, err := parseMessage(options...)
+	if err != nil || !m.oversized() {
+		_, err = client.PostEphemeralContext(ctx, channel, userID, options...)
+		return err
+	}
+	for _, part := range m.split() {
+		if _, err = client.PostEphemeralContext(ctx, channel, userID, part.options()...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// An OversizePolicy determines how the Bot posts a message that exceeds Slack's limits (40,000 characters of text, or
+// 50 blocks).
+type OversizePolicy int
+
+const (
+	// OversizeUpload uploads the message's text as a file. If the message has too many blocks, the blocks are split
+	// as per OversizeSplit. This is the default.
+	OversizeUpload OversizePolicy = iota
+	// OversizeSplit splits the message into several messages, posted as replies in the thread of the first message.
+	OversizeSplit
+)
+
+func (p OversizePolicy) String() string {
+	switch p {
+	case OversizeUpload:
+		return "upload"
+	case OversizeSplit:
+		return "split"
+	default:
+		return fmt.Sprintf("OversizePolicy(%d)", int(p))
+	}
+}
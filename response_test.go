@@ -0,0 +1,113 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBot_run(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	api := slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/"))
+	b := NewBot(api, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	req := Request{ChannelID: "C1", UserID: "U1"}
+	err := b.run(context.Background(), req, func(ctx context.Context) []slack.MsgOption {
+		r, ok := RequestFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, req, r)
+		assert.True(t, Respond(ctx,
+			Ephemeral(slack.MsgOptionText("ephemeral", false)),
+			DirectMessage(slack.MsgOptionText("dm", false)),
+			InChannel("C2", slack.MsgOptionText("other", false)),
+		))
+		return []slack.MsgOption{slack.MsgOptionText("public", false)}
+	})
+	require.NoError(t, err)
+
+	want := []struct {
+		channel string
+		user    string
+		text    string
+	}{
+		{channel: "C1", text: "public"},
+		{channel: "C1", user: "U1", text: "ephemeral"},
+		{channel: "D1", text: "dm"},
+		{channel: "C2", text: "other"},
+	}
+	for _, w := range want {
+		post := <-ts.post
+		assert.Equal(t, w.channel, post.Get("channel"))
+		assert.Equal(t, w.user, post.Get("user"))
+		assert.Equal(t, w.text, post.Get("text"))
+	}
+
+	// responses to a user require a user
+	err = b.run(context.Background(), Request{ChannelID: "C1"}, func(ctx context.Context) []slack.MsgOption {
+		Respond(ctx, Ephemeral(slack.MsgOptionText("ephemeral", false)))
+		return nil
+	})
+	assert.ErrorIs(t, err, errNoUser)
+}
+
+func TestRespond(t *testing.T) {
+	assert.False(t, Respond(context.Background(), Public(slack.MsgOptionText("foo", false))))
+	_, ok := RequestFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestBot_post_Oversized(t *testing.T) {
+	text := strings.Repeat("line\n", maxTextLength/5) + "last"
+
+	t.Run("upload", func(t *testing.T) {
+		ts := testServer{t: t, post: make(chan url.Values, 10), uploads: make(chan url.Values, 10)}
+		s := httptest.NewServer(&ts)
+		defer s.Close()
+		b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+		err := b.post(context.Background(), Request{ChannelID: "C1"}, Public(slack.MsgOptionText(codeBlock(text), false), slack.MsgOptionAttachments(slack.Attachment{Title: "foo"})))
+		require.NoError(t, err)
+		upload := <-ts.uploads
+		assert.Equal(t, "C1", upload.Get("channel"))
+		assert.Equal(t, "output.txt:"+text, upload.Get("file"))
+		post := <-ts.post
+		assert.Empty(t, post.Get("text"))
+		assert.Equal(t, `[{"title":"foo","blocks":null}]`, post.Get("attachments"))
+		assert.Empty(t, ts.post)
+	})
+
+	t.Run("split", func(t *testing.T) {
+		ts := testServer{t: t, post: make(chan url.Values, 10)}
+		s := httptest.NewServer(&ts)
+		defer s.Close()
+		b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+			WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+			WithOversizePolicy(OversizeSplit),
+		)
+
+		require.NoError(t, b.post(context.Background(), Request{ChannelID: "C1"}, Public(slack.MsgOptionText(text, false))))
+		post := <-ts.post
+		assert.Equal(t, strings.Repeat("line\n", maxTextLength/5-1)+"line", post.Get("text"))
+		assert.Empty(t, post.Get("thread_ts"))
+		post = <-ts.post
+		assert.Equal(t, "last", post.Get("text"))
+		assert.Equal(t, "100.0", post.Get("thread_ts"))
+
+		// ephemeral messages are split, but not threaded
+		require.NoError(t, b.post(context.Background(), Request{ChannelID: "C1", UserID: "U1"}, Ephemeral(slack.MsgOptionText(text, false))))
+		assert.Empty(t, (<-ts.post).Get("thread_ts"))
+		post = <-ts.post
+		assert.Equal(t, "last", post.Get("text"))
+		assert.Empty(t, post.Get("thread_ts"))
+	})
+}
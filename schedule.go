@@ -0,0 +1,195 @@
+package slackapp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schedule determines when a scheduled job runs.
+type Schedule interface {
+	// Next returns the first time after t when the job should run.
+	Next(t time.Time) time.Time
+	// String returns the schedule's specification.
+	String() string
+}
+
+// ParseSchedule parses a schedule specification. It supports:
+//
+//   - standard cron expressions, with five fields: minute, hour, day of month, month and day of week. Each field
+//     can be a wildcard (*), a value (5), a range (1-5), a step (*/15 or 0-30/10) or a comma-separated list of these.
+//   - "@every <duration>", where duration is parsed by time.ParseDuration (e.g. "@every 1h30m").
+//   - "@hourly", "@daily", "@weekly" and "@monthly".
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		return parseCron(spec, "0 * * * *")
+	case "@daily":
+		return parseCron(spec, "0 0 * * *")
+	case "@weekly":
+		return parseCron(spec, "0 0 * * 0")
+	case "@monthly":
+		return parseCron(spec, "0 0 1 * *")
+	}
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid interval: %s", d)
+		}
+		return Every(d), nil
+	}
+	return parseCron(spec, spec)
+}
+
+// MustParseSchedule is like ParseSchedule but panics if the specification cannot be parsed.
+func MustParseSchedule(spec string) Schedule {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Every returns a Schedule that runs at a fixed interval.
+func Every(interval time.Duration) Schedule {
+	return every(interval)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type cronSchedule struct {
+	spec                     string
+	minute, hour, dom        uint64
+	month, dow               uint64
+	domWildcard, dowWildcard bool
+}
+
+var cronFields = []struct {
+	name        string
+	first, last int
+}{
+	{name: "minute", first: 0, last: 59},
+	{name: "hour", first: 0, last: 23},
+	{name: "day of month", first: 1, last: 31},
+	{name: "month", first: 1, last: 12},
+	{name: "day of week", first: 0, last: 7},
+}
+
+func parseCron(spec, expression string) (Schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected %d fields", spec, len(cronFields))
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		var err error
+		if bits[i], err = parseCronField(field, cronFields[i].first, cronFields[i].last); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s: %w", spec, cronFields[i].name, err)
+		}
+	}
+	// Sunday can be specified as 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSchedule{
+		spec:        spec,
+		minute:      bits[0],
+		hour:        bits[1],
+		dom:         bits[2],
+		month:       bits[3],
+		dow:         bits[4],
+		domWildcard: strings.HasPrefix(fields[2], "*"),
+		dowWildcard: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, first, last int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepSpec)
+			}
+		}
+		low, high := first, last
+		if rangeSpec != "*" {
+			lowSpec, highSpec, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if low, err = strconv.Atoi(lowSpec); err != nil {
+				return 0, fmt.Errorf("invalid value %q", lowSpec)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highSpec); err != nil {
+					return 0, fmt.Errorf("invalid value %q", highSpec)
+				}
+			} else if hasStep {
+				high = last
+			}
+		}
+		if low < first || high > last || low > high {
+			return 0, fmt.Errorf("value out of range %q", rangeSpec)
+		}
+		for i := low; i <= high; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) String() string {
+	return c.spec
+}
+
+// Next returns the first time after t matching the schedule. If no such time exists within the next five years
+// (e.g. for "0 0 30 2 *"), it returns the zero time.
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron's convention: if both day of month and day of week are restricted, the day matches if either matches.
+// A field that starts with "*" (e.g. "*/2") is unrestricted.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domWildcard || c.dowWildcard {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,126 @@
+package slackapp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 30, 15, 0, time.UTC) // a Friday
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr assert.ErrorAssertionFunc
+		want    []time.Time
+	}{
+		{
+			name:    "every minute",
+			spec:    "* * * * *",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.March, 1, 10, 31, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 10, 32, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "weekdays",
+			spec:    "0 9 * * 1-5",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 5, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "steps and lists",
+			spec:    "*/20 10,12 * * *",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.March, 1, 10, 40, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 12, 20, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "day of month or day of week",
+			spec:    "0 0 15 * 0",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "stepped wildcard is unrestricted",
+			spec:    "0 0 */2 * 1",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "sunday as 7",
+			spec:    "0 0 * * 7",
+			wantErr: assert.NoError,
+			want:    []time.Time{time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:    "monthly",
+			spec:    "@monthly",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "interval",
+			spec:    "@every 1h0m0s",
+			wantErr: assert.NoError,
+			want: []time.Time{
+				time.Date(2024, time.March, 1, 11, 30, 15, 0, time.UTC),
+				time.Date(2024, time.March, 1, 12, 30, 15, 0, time.UTC),
+			},
+		},
+		{
+			name:    "never",
+			spec:    "0 0 30 2 *",
+			wantErr: assert.NoError,
+			want:    []time.Time{{}},
+		},
+		{name: "too few fields", spec: "* * * *", wantErr: assert.Error},
+		{name: "invalid value", spec: "foo * * * *", wantErr: assert.Error},
+		{name: "out of range", spec: "60 * * * *", wantErr: assert.Error},
+		{name: "invalid step", spec: "*/0 * * * *", wantErr: assert.Error},
+		{name: "invalid range", spec: "5-1 * * * *", wantErr: assert.Error},
+		{name: "invalid interval", spec: "@every foo", wantErr: assert.Error},
+		{name: "negative interval", spec: "@every -1h", wantErr: assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.spec, s.String())
+			next := start
+			for _, want := range tt.want {
+				next = s.Next(next)
+				assert.Equal(t, want, next)
+			}
+		})
+	}
+}
+
+func TestMustParseSchedule(t *testing.T) {
+	require.NotPanics(t, func() { MustParseSchedule("@daily") })
+	require.Panics(t, func() { MustParseSchedule("@never") })
+}
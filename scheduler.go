@@ -0,0 +1,323 @@
+package slackapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/slack-go/slack"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Job runs a command on a schedule and posts its output to a channel.
+type Job struct {
+	ID       string
+	Schedule Schedule
+	TeamID   string
+	Channel  string
+	Command  []string
+	next     time.Time
+}
+
+// A scheduler runs jobs on their schedule. Jobs added by users are saved in the store (if set), so they survive a restart.
+type scheduler struct {
+	jobs    map[string]*Job
+	lastID  int
+	store   Store
+	changed chan struct{}
+	lock    sync.Mutex
+}
+
+func (s *scheduler) add(schedule Schedule, teamID string, channel string, command ...string) Job {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]*Job)
+	}
+	s.lastID++
+	job := Job{
+		ID:       strconv.Itoa(s.lastID),
+		Schedule: schedule,
+		TeamID:   teamID,
+		Channel:  channel,
+		Command:  command,
+		next:     schedule.Next(time.Now()),
+	}
+	s.jobs[job.ID] = &job
+	s.notify()
+	return job
+}
+
+func (s *scheduler) remove(ctx context.Context, id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, ok := s.jobs[id]
+	delete(s.jobs, id)
+	s.notify()
+	if !ok || s.store == nil {
+		return ok, nil
+	}
+	return ok, s.store.Delete(ctx, id)
+}
+
+type storedJob struct {
+	Schedule string   `json:"schedule"`
+	TeamID   string   `json:"team_id,omitempty"`
+	Channel  string   `json:"channel"`
+	Command  []string `json:"command"`
+}
+
+// save saves the job in the store.
+func (s *scheduler) save(ctx context.Context, job Job) error {
+	if s.store == nil {
+		return nil
+	}
+	body, err := json.Marshal(storedJob{Schedule: job.Schedule.String(), TeamID: job.TeamID, Channel: job.Channel, Command: job.Command})
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, job.ID, body)
+}
+
+// load adds all jobs saved in the store. If a saved job's ID is already in use (e.g. by a job registered with WithSchedule),
+// the job gets a new ID.
+func (s *scheduler) load(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	ids, err := s.store.Keys(ctx, "")
+	if err != nil {
+		return fmt.Errorf("load jobs: %w", err)
+	}
+	var errs error
+	for _, id := range ids {
+		if err = s.loadJob(ctx, id); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("load job %s: %w", id, err))
+		}
+	}
+	return errs
+}
+
+func (s *scheduler) loadJob(ctx context.Context, id string) error {
+	body, err := s.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	var stored storedJob
+	if err = json.Unmarshal(body, &stored); err != nil {
+		return err
+	}
+	schedule, err := ParseSchedule(stored.Schedule)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]*Job)
+	}
+	_, inUse := s.jobs[id]
+	numericID, err := strconv.Atoi(id)
+	keepID := !inUse && err == nil
+	if keepID {
+		s.jobs[id] = &Job{ID: id, Schedule: schedule, TeamID: stored.TeamID, Channel: stored.Channel, Command: stored.Command, next: schedule.Next(time.Now())}
+		s.lastID = max(s.lastID, numericID)
+		s.notify()
+	}
+	s.lock.Unlock()
+	if keepID {
+		return nil
+	}
+
+	job := s.add(schedule, stored.TeamID, stored.Channel, stored.Command...)
+	if err = s.save(ctx, job); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, id)
+}
+
+// list returns all jobs, sorted by ID.
+func (s *scheduler) list() []Job {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	slices.SortFunc(jobs, func(a, b Job) int {
+		idA, _ := strconv.Atoi(a.ID)
+		idB, _ := strconv.Atoi(b.ID)
+		return idA - idB
+	})
+	return jobs
+}
+
+// notify wakes up the scheduler when jobs are added or removed. Must be called with the lock held.
+func (s *scheduler) notify() {
+	if s.changed == nil {
+		s.changed = make(chan struct{}, 1)
+	}
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the time when the next job is due. It returns false if no jobs are scheduled.
+func (s *scheduler) next() (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var next time.Time
+	for _, job := range s.jobs {
+		if !job.next.IsZero() && (next.IsZero() || job.next.Before(next)) {
+			next = job.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// due returns all jobs that are due at time now and schedules their next run.
+func (s *scheduler) due(now time.Time) []Job {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var jobs []Job
+	for _, job := range s.jobs {
+		if !job.next.IsZero() && !job.next.After(now) {
+			jobs = append(jobs, *job)
+			job.next = job.Schedule.Next(now)
+		}
+	}
+	return jobs
+}
+
+func (s *scheduler) run(ctx context.Context, execute func(context.Context, Job)) {
+	s.lock.Lock()
+	s.notify()
+	changed := s.changed
+	s.lock.Unlock()
+
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next, ok := s.next(); ok {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case now := <-due:
+			for _, job := range s.due(now) {
+				execute(ctx, job)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (b *Bot) runJob(ctx context.Context, job Job) {
+	path, params := b.redact(ctx, job.Command)
+	b.logger.Debug("running scheduled job", "id", job.ID, "channel", job.Channel, "cmd", strings.Join(append(path, params...), " "))
+	err := b.run(ctx, Request{TeamID: job.TeamID, ChannelID: job.Channel}, b.audited(nil, job.Command, func(ctx context.Context) []slack.MsgOption {
+		return b.Handle(ctx, job.Command...)
+	}))
+	if err != nil {
+		b.logger.Warn("failed to post scheduled job output", "id", job.ID, "channel", job.Channel, "err", err)
+	}
+}
+
+// Jobs returns all scheduled jobs.
+func (b *Bot) Jobs() []Job {
+	return b.scheduler.list()
+}
+
+// scheduleCommands returns the commands to manage scheduled jobs:
+//
+//	schedule add <schedule> <channel> <command>
+//	schedule list
+//	schedule rm <id>
+func (b *Bot) scheduleCommands() Commands {
+	return Commands{
+		"add":  HandlerFunc(b.addJob),
+		"list": HandlerFunc(b.listJobs),
+		"rm":   HandlerFunc(b.removeJob),
+	}
+}
+
+func (b *Bot) addJob(ctx context.Context, args ...string) []slack.MsgOption {
+	if len(args) < 3 {
+		return errorMessage(ctx, "invalid arguments", Translate(ctx, "usage: schedule add <schedule> <channel> <command>"))
+	}
+	schedule, err := ParseSchedule(args[0])
+	if err != nil {
+		return errorMessage(ctx, "invalid schedule", err.Error())
+	}
+	channel, ok := parseChannel(args[1])
+	if !ok {
+		return errorMessage(ctx, "invalid channel", args[1])
+	}
+	req, _ := RequestFromContext(ctx)
+	job := b.scheduler.add(schedule, req.TeamID, channel, args[2:]...)
+	if err = b.scheduler.save(ctx, job); err != nil {
+		b.logger.Warn("failed to save job", "id", job.ID, "err", err)
+	}
+	return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "scheduled job %s: %s", job.ID, formatJob(job)), false)}
+}
+
+func (b *Bot) listJobs(ctx context.Context, _ ...string) []slack.MsgOption {
+	jobs := b.Jobs()
+	if len(jobs) == 0 {
+		return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "no jobs scheduled"), false)}
+	}
+	lines := make([]string, len(jobs))
+	for i, job := range jobs {
+		lines[i] = job.ID + ": " + formatJob(job)
+	}
+	return []slack.MsgOption{slack.MsgOptionText(strings.Join(lines, "\n"), false)}
+}
+
+func (b *Bot) removeJob(ctx context.Context, args ...string) []slack.MsgOption {
+	if len(args) != 1 {
+		return errorMessage(ctx, "invalid arguments", Translate(ctx, "usage: schedule rm <id>"))
+	}
+	ok, err := b.scheduler.remove(ctx, args[0])
+	if err != nil {
+		b.logger.Warn("failed to remove job", "id", args[0], "err", err)
+	}
+	if !ok {
+		return errorMessage(ctx, "invalid job", Translate(ctx, "no job with id %s", args[0]))
+	}
+	return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "removed job %s", args[0]), false)}
+}
+
+func formatJob(job Job) string {
+	output := fmt.Sprintf("`%s` in <#%s>: %s", job.Schedule, job.Channel, strings.Join(job.Command, " "))
+	if !job.next.IsZero() {
+		output += " (next run: " + job.next.Format(time.DateTime) + ")"
+	}
+	return output
+}
+
+var channelRegExp = regexp.MustCompile(`^<#(\w+)(\|[^>]*)?>$|^([CG][A-Z0-9]+)$`)
+
+// parseChannel returns the channel ID from a channel mention (e.g. "<#C12345|general>") or a channel ID.
+func parseChannel(arg string) (string, bool) {
+	matches := channelRegExp.FindStringSubmatch(arg)
+	if matches == nil {
+		return "", false
+	}
+	if matches[1] != "" {
+		return matches[1], true
+	}
+	return matches[3], true
+}
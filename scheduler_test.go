@@ -0,0 +1,164 @@
+package slackapp
+
+import (
+	"bytes"
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBot_Schedule(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	api := slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/"))
+	b := NewBot(api,
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("report", HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("report", false)}
+		})),
+		WithSchedule(Every(10*time.Millisecond), "C1", "report", "daily"),
+		WithScheduleCommand(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.scheduler.run(ctx, b.runJob)
+
+	post := <-ts.post
+	assert.Equal(t, "C1", post.Get("channel"))
+	assert.Equal(t, "report", post.Get("text"))
+
+	// remove the job
+	output := formatMessage(b.Handle(ctx, "schedule", "rm", "1"))
+	assert.Equal(t, "removed job 1", output.Get("text"))
+	assert.Empty(t, b.Jobs())
+
+	// add a job
+	output = formatMessage(b.Handle(ctx, "schedule", "add", "@every 10ms", "<#C2|ops>", "report", "hourly"))
+	assert.Contains(t, output.Get("text"), "scheduled job 2: `@every 10ms` in <#C2>: report hourly")
+	// the removed job may still have been running: wait for the new job's output
+	for post = <-ts.post; post.Get("channel") != "C2"; post = <-ts.post {
+	}
+
+	jobs := b.Jobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, []string{"report", "hourly"}, jobs[0].Command)
+	output = formatMessage(b.Handle(ctx, "schedule", "list"))
+	assert.Contains(t, output.Get("text"), "2: `@every 10ms` in <#C2>: report hourly")
+	_, _ = b.scheduler.remove(ctx, "2")
+	output = formatMessage(b.Handle(ctx, "schedule", "list"))
+	assert.Equal(t, "no jobs scheduled", output.Get("text"))
+}
+
+func TestBot_Schedule_Errors(t *testing.T) {
+	b := makeBot(WithScheduleCommand())
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "missing arguments", args: []string{"add", "@daily", "C1"}, want: "invalid arguments"},
+		{name: "invalid schedule", args: []string{"add", "@never", "C1", "report"}, want: "invalid schedule"},
+		{name: "invalid channel", args: []string{"add", "@daily", "#ops", "report"}, want: "invalid channel"},
+		{name: "missing id", args: []string{"rm"}, want: "invalid arguments"},
+		{name: "invalid id", args: []string{"rm", "1"}, want: "invalid job"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := formatMessage(b.Handle(context.Background(), append([]string{"schedule"}, tt.args...)...))
+			assert.Contains(t, output.Get("attachments"), `"title":"`+tt.want+`"`)
+		})
+	}
+}
+
+func Test_parseChannel(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{input: "<#C123|ops>", want: "C123", wantOK: true},
+		{input: "<#C123>", want: "C123", wantOK: true},
+		{input: "C123", want: "C123", wantOK: true},
+		{input: "#ops", wantOK: false},
+		{input: "<@U123>", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			channel, ok := parseChannel(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, channel)
+		})
+	}
+}
+
+func TestBot_Schedule_Store(t *testing.T) {
+	var s MemoryStore
+	ctx := context.Background()
+
+	b := makeBot(WithStore(&s), WithScheduleCommand())
+	output := formatMessage(b.Handle(ctx, "schedule", "add", "@daily", "C1", "report"))
+	assert.Contains(t, output.Get("text"), "scheduled job 1")
+	output = formatMessage(b.Handle(ctx, "schedule", "add", "@hourly", "C1", "alerts"))
+	assert.Contains(t, output.Get("text"), "scheduled job 2")
+	_ = b.Handle(ctx, "schedule", "rm", "2")
+
+	// jobs added by users are loaded when the bot restarts. IDs already in use are replaced.
+	b = makeBot(WithStore(&s), WithSchedule(Every(time.Hour), "C2", "status"))
+	require.NoError(t, b.scheduler.load(ctx))
+	jobs := b.Jobs()
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "1", jobs[0].ID)
+	assert.Equal(t, []string{"status"}, jobs[0].Command)
+	assert.Equal(t, "2", jobs[1].ID)
+	assert.Equal(t, "@daily", jobs[1].Schedule.String())
+	assert.Equal(t, []string{"report"}, jobs[1].Command)
+
+	keys, err := s.Keys(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bot/schedules/2"}, keys)
+
+	// invalid jobs are reported
+	require.NoError(t, s.Set(ctx, "bot/schedules/3", []byte("invalid")))
+	b = makeBot(WithStore(&s))
+	assert.Error(t, b.scheduler.load(ctx))
+}
+
+func TestWithWorkspaceSchedule(t *testing.T) {
+	b := makeBot(WithSchedule(Every(time.Hour), "C1", "status"), WithWorkspaceSchedule("T1", Every(time.Hour), "C2", "report"))
+	jobs := b.Jobs()
+	require.Len(t, jobs, 2)
+	assert.Empty(t, jobs[0].TeamID)
+	assert.Equal(t, "T1", jobs[1].TeamID)
+	assert.Equal(t, "C2", jobs[1].Channel)
+}
+
+func TestBot_runJob_Redacted(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 1)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	var logs bytes.Buffer
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithCommand("login", Sensitive{Handler: HandlerFunc(func(_ context.Context, _ ...string) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText("logged in", false)}
+		}), Args: []int{1}}),
+	)
+
+	b.runJob(context.Background(), Job{ID: "1", Channel: "C1", Command: []string{"login", "admin", "secret"}})
+	assert.Equal(t, "logged in", (<-ts.post).Get("text"))
+	assert.Contains(t, logs.String(), `cmd="login admin [REDACTED]"`)
+	assert.NotContains(t, logs.String(), "secret")
+}
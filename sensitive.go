@@ -0,0 +1,121 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"github.com/slack-go/slack"
+	"slices"
+)
+
+var _ Handler = Sensitive{}
+
+// Sensitive is a Handler for commands whose arguments may hold secrets, e.g. passwords or tokens. The Bot redacts the
+// sensitive arguments in its logs, in the users' history and in the audit log:
+//
+//	bot := slackapp.NewBot(client,
+//		slackapp.WithCommand("login", slackapp.Sensitive{Handler: login, Args: []int{1}, DeleteMessage: true}),
+//	)
+type Sensitive struct {
+	Handler Handler
+	// Args holds the positions of the sensitive arguments, starting at 0. If empty, all arguments are sensitive.
+	Args []int
+	// DeleteMessage deletes the message containing the command, so the secret doesn't remain visible in the channel, and
+	// posts the Handler's output as an ephemeral response. The Bot posts the other replies to the message (e.g. the
+	// output of the other commands in the message, or Responses added with Public) as ephemeral responses too, and
+	// doesn't re-execute the message if it's edited. Deleting a user's message requires a user token (see WithUserToken).
+	DeleteMessage bool
+}
+
+// Handle calls the Handler. If DeleteMessage is set, the Handler's output is posted as an ephemeral response.
+func (s Sensitive) Handle(ctx context.Context, args ...string) []slack.MsgOption {
+	output := s.Handler.Handle(ctx, args...)
+	if s.DeleteMessage && len(output) > 0 && Respond(ctx, Ephemeral(output...)) {
+		return nil
+	}
+	return output
+}
+
+func (s Sensitive) redact(args []string) []string {
+	redactedArgs := slices.Clone(args)
+	for i := range redactedArgs {
+		if len(s.Args) == 0 || slices.Contains(s.Args, i) {
+			redactedArgs[i] = redacted
+		}
+	}
+	return redactedArgs
+}
+
+// redact returns the values, with the values of Sensitive arguments redacted.
+func (f Form) redact(values []string) []string {
+	redactedValues := slices.Clone(values)
+	for i := range redactedValues {
+		if i < len(f.Arguments) && f.Arguments[i].Sensitive {
+			redactedValues[i] = redacted
+		}
+	}
+	return redactedValues
+}
+
+// unwrap returns the Handler wrapped by the handler (e.g. by RateLimited), or the handler itself if it doesn't wrap
+// another Handler.
+func unwrap(handler Handler) Handler {
+	for {
+		wrapper, ok := handler.(interface{ Unwrap() Handler })
+		if !ok {
+			return handler
+		}
+		handler = wrapper.Unwrap()
+	}
+}
+
+// sensitive returns the handler as a Sensitive, if it is one or wraps one.
+func sensitive(handler Handler) (Sensitive, bool) {
+	switch h := unwrap(handler).(type) {
+	case Sensitive:
+		return h, true
+	case *Sensitive:
+		return *h, true
+	default:
+		return Sensitive{}, false
+	}
+}
+
+// redact returns the command's path and its arguments, with sensitive arguments redacted: the arguments marked as
+// sensitive by the command's Handler (a Sensitive or a Form), and the arguments redacted by the Bot's Redactor.
+func (b *Bot) redact(ctx context.Context, args []string) ([]string, []string) {
+	handler, path, params := b.Commands.resolve(withMatching(ctx, b.matching), args...)
+	handler = unwrap(handler)
+	if s, ok := sensitive(handler); ok {
+		params = s.redact(params)
+	} else if form, ok := handler.(Form); ok {
+		params = form.redact(params)
+	} else if form, ok := handler.(*Form); ok {
+		params = form.redact(params)
+	}
+	if b.redactor != nil {
+		params = b.redactor(path, params)
+	}
+	return path, params
+}
+
+// deletesMessage returns true if any of the commands deletes the message containing them (see Sensitive).
+func (b *Bot) deletesMessage(ctx context.Context, commands []batchCommand) bool {
+	for _, command := range commands {
+		handler, _, _ := b.Commands.resolve(withMatching(ctx, b.matching), arguments(command.tokens)...)
+		if s, ok := sensitive(handler); ok && s.DeleteMessage {
+			return true
+		}
+	}
+	return false
+}
+
+var errNoUserToken = errors.New("no user token")
+
+// deleteMessage deletes the message containing the command, using the user token.
+func (b *Bot) deleteMessage(ctx context.Context, req Request) error {
+	if b.userClient == nil {
+		return errNoUserToken
+	}
+	_, _, err := b.userClient.DeleteMessageContext(ctx, req.ChannelID, req.TS)
+	return err
+}
@@ -0,0 +1,117 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBot_Sensitive(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), deletes: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+	})
+	var records []AuditRecord
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithUserToken("xoxp-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithAudit(AuditSinkFunc(func(_ context.Context, record AuditRecord) error {
+			records = append(records, record)
+			return nil
+		})),
+		WithCommand("login", Sensitive{Handler: echo, Args: []int{1}}),
+		WithCommand("token", Sensitive{Handler: echo, DeleteMessage: true}),
+		WithCommand("register", Form{Handler: echo, Arguments: []Argument{{Name: "user"}, {Name: "password", Sensitive: true}}}),
+		WithCommand("limited", RateLimited(Sensitive{Handler: echo}, RateLimit{PerUser: Limit{Burst: 10, Interval: time.Minute}})),
+		WithFallback(Intents{{Pattern: regexp.MustCompile(`^my password is (\S+)`), Command: []string{"login", "me", "$1"}}}),
+	)
+
+	tests := []struct {
+		name        string
+		input       string
+		wantUser    string
+		wantHistory string
+		wantDeleted bool
+		ts          string
+	}{
+		{name: "sensitive argument", input: "login admin secret", wantHistory: "login admin [REDACTED]", ts: "1.0"},
+		{name: "all arguments", input: "token xoxb-1 xoxb-2", wantUser: "U1", wantHistory: "token [REDACTED] [REDACTED]", wantDeleted: true, ts: "2.0"},
+		{name: "form", input: "register admin secret", wantHistory: "register admin [REDACTED]", ts: "3.0"},
+		{name: "rate limited", input: "limited secret", wantHistory: "limited [REDACTED]", ts: "4.0"},
+		{name: "fallback", input: "my password is secret", wantHistory: "login me [REDACTED]", ts: "5.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records = nil
+			req := Request{ChannelID: "C1", UserID: "U1", TS: tt.ts}
+			require.NoError(t, b.handle(context.Background(), req, "<@W23456789> "+tt.input))
+
+			post := <-ts.post
+			assert.Equal(t, tt.wantUser, post.Get("user"))
+			assert.Equal(t, tt.wantHistory, b.History("U1")[0].Command)
+			require.Len(t, records, 1)
+			assert.Equal(t, tt.wantHistory, strings.Join(append(records[0].Command, records[0].Args...), " "))
+
+			if tt.wantDeleted {
+				deleted := <-ts.deletes
+				assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {tt.ts}, "token": {"xoxp-foo"}}, deleted)
+			}
+			assert.Empty(t, ts.deletes)
+		})
+	}
+}
+
+func TestBot_Sensitive_Batch(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10), deletes: make(chan url.Values, 10), updates: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	echo := HandlerFunc(func(_ context.Context, args ...string) []slack.MsgOption {
+		return []slack.MsgOption{slack.MsgOptionText(strings.Join(args, " "), false)}
+	})
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithUserToken("xoxp-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithBatchCommands(BatchCombined),
+		WithCommand("token", Sensitive{Handler: HandlerFunc(func(ctx context.Context, args ...string) []slack.MsgOption {
+			Respond(ctx, Public(slack.MsgOptionText("token set", false)))
+			return echo(ctx, args...)
+		}), DeleteMessage: true}),
+		WithCommand("echo", echo),
+	)
+	ctx := context.Background()
+
+	// all replies to a message with a sensitive command are ephemeral
+	req := Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}
+	require.NoError(t, b.handle(ctx, req, "<@W23456789> token secret && echo foo"))
+	assert.Equal(t, url.Values{"channel": {"C1"}, "ts": {"1.0"}, "token": {"xoxp-foo"}}, <-ts.deletes)
+	require.Len(t, ts.post, 2)
+	for range 2 {
+		assert.Equal(t, "U1", (<-ts.post).Get("user"))
+	}
+
+	// the replies aren't tracked: editing the message doesn't re-execute it
+	_, ok := b.replies.get(req)
+	assert.False(t, ok)
+	b.onEdit(ctx, "", &slackevents.MessageEvent{
+		Channel:         "C1",
+		Message:         &slackevents.MessageEvent{User: "U1", Text: "<@W23456789> token other && echo foo", TimeStamp: "1.0"},
+		PreviousMessage: &slackevents.MessageEvent{User: "U1", Text: "<@W23456789> token secret && echo foo", TimeStamp: "1.0"},
+	})
+	assert.Empty(t, ts.post)
+	assert.Empty(t, ts.updates)
+	assert.Empty(t, ts.deletes)
+}
@@ -0,0 +1,245 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Step handles the user's reply in a session and returns the Bot's response, and the Step that handles the user's
+// next reply. Returning a nil Step ends the session.
+//
+// Steps keep the session's state in their closure:
+//
+//	func onboard(ctx context.Context, _ ...string) []slack.MsgOption {
+//		slackapp.StartSession(ctx, func(ctx context.Context, team string) ([]slack.MsgOption, slackapp.Step) {
+//			return text("what's your role?"), func(ctx context.Context, role string) ([]slack.MsgOption, slackapp.Step) {
+//				return text("welcome to " + team + ", " + role), nil
+//			}
+//		})
+//		return text("what's your team?")
+//	}
+type Step func(ctx context.Context, reply string) ([]slack.MsgOption, Step)
+
+// StartSession starts a session with the user that issued the command: until the session ends, the user's messages in
+// the command's thread are passed to the session's current Step, instead of being executed as commands. The Bot posts
+// the command's output, and the session's responses, in the thread. In a direct message, all the user's messages are
+// passed to the session.
+//
+// A session ends when a Step returns a nil Step, when the user replies "cancel", or when the user doesn't reply within
+// the session timeout (see WithSessionTimeout). StartSession replaces any active session in the thread. It returns
+// false if the command wasn't issued through a Bot, or wasn't issued by a user (e.g. a scheduled job).
+func StartSession(ctx context.Context, step Step) bool {
+	s, ok := ctx.Value(sessionsKey{}).(*sessions)
+	req, _ := RequestFromContext(ctx)
+	r, _ := ctx.Value(responsesKey{}).(*responses)
+	if !ok || r == nil || req.UserID == "" {
+		return false
+	}
+	s.start(req, step)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.threadTS = sessionThread(req)
+	return true
+}
+
+// defaultSessionTimeout is the time a session waits for the user's reply.
+const defaultSessionTimeout = 5 * time.Minute
+
+type sessions struct {
+	timeout time.Duration
+	expire  func(Request)
+	active  map[sessionKey]*session
+	// passed holds the most recent messages that started a session or were passed to one, so the Bot recognizes
+	// messages it receives twice (e.g. as both a message and a mention event).
+	passed []messageKey
+	lock   sync.Mutex
+}
+
+// maxPassed is the number of messages sessions remember in passed.
+const maxPassed = 100
+
+type messageKey struct {
+	teamID    string
+	channelID string
+	ts        string
+}
+
+type sessionsKey struct{}
+
+func withSessions(ctx context.Context, s *sessions) context.Context {
+	return context.WithValue(ctx, sessionsKey{}, s)
+}
+
+type sessionKey struct {
+	teamID    string
+	userID    string
+	channelID string
+	threadTS  string
+}
+
+type session struct {
+	req   Request
+	step  Step
+	timer *time.Timer
+}
+
+// sessionThread returns the thread of the session started by the request. In a direct message, sessions are not threaded.
+func sessionThread(req Request) string {
+	if strings.HasPrefix(req.ChannelID, "D") {
+		return ""
+	}
+	if req.ThreadTS != "" {
+		return req.ThreadTS
+	}
+	return req.TS
+}
+
+func (s *sessions) start(req Request, step Step) {
+	req.ThreadTS = sessionThread(req)
+	key := sessionKey{teamID: req.TeamID, userID: req.UserID, channelID: req.ChannelID, threadTS: req.ThreadTS}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.active == nil {
+		s.active = make(map[sessionKey]*session)
+	}
+	if current, ok := s.active[key]; ok {
+		current.timer.Stop()
+	}
+	current := &session{req: req, step: step}
+	current.timer = time.AfterFunc(s.timeout, func() { s.expired(key, current) })
+	s.active[key] = current
+	s.pass(req)
+}
+
+// pass records that the request's message started a session or was passed to one. The caller must hold the lock.
+func (s *sessions) pass(req Request) {
+	s.passed = append(s.passed, messageKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS})
+	if len(s.passed) > maxPassed {
+		s.passed = s.passed[1:]
+	}
+}
+
+// expired ends the session, if it's still active, and notifies the user.
+func (s *sessions) expired(key sessionKey, current *session) {
+	s.lock.Lock()
+	if s.active[key] != current {
+		s.lock.Unlock()
+		return
+	}
+	delete(s.active, key)
+	expire := s.expire
+	s.lock.Unlock()
+	if expire != nil {
+		expire(current.req)
+	}
+}
+
+// next returns the current Step of the user's active session in the request's thread, and removes the Step from the
+// session until the next Step is set with resume. It returns false if no session is active. duplicate is true if the
+// message was already passed to a session, or started one (e.g. when the Bot receives it as both a message and a
+// mention event).
+func (s *sessions) next(req Request) (resumed Request, step Step, ok bool, duplicate bool) {
+	if strings.HasPrefix(req.ChannelID, "D") {
+		req.ThreadTS = ""
+	}
+	key := sessionKey{teamID: req.TeamID, userID: req.UserID, channelID: req.ChannelID, threadTS: req.ThreadTS}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if req.TS != "" && slices.Contains(s.passed, messageKey{teamID: req.TeamID, channelID: req.ChannelID, ts: req.TS}) {
+		return Request{}, nil, false, true
+	}
+	current, ok := s.active[key]
+	if !ok || current.step == nil {
+		return Request{}, nil, false, false
+	}
+	current.timer.Reset(s.timeout)
+	s.pass(req)
+	step = current.step
+	current.step = nil
+	req.ThreadTS = current.req.ThreadTS
+	return req, step, true, false
+}
+
+// resume sets the session's next Step. If step is nil, the session ends.
+func (s *sessions) resume(req Request, step Step) {
+	key := sessionKey{teamID: req.TeamID, userID: req.UserID, channelID: req.ChannelID, threadTS: req.ThreadTS}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	current, ok := s.active[key]
+	if !ok || current.step != nil {
+		// the session expired, or a new session was started
+		return
+	}
+	if step == nil {
+		current.timer.Stop()
+		delete(s.active, key)
+		return
+	}
+	current.step = step
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// converse passes the message to the user's active session in the message's thread. It returns false if no session
+// is active. Messages the Bot receives twice (e.g. as both a message and a mention event) are only passed once: converse
+// returns true for the duplicate, so it isn't executed as a command either.
+func (b *Bot) converse(ctx context.Context, req Request, text string) bool {
+	req, step, ok, duplicate := b.sessions.next(req)
+	if duplicate {
+		return true
+	}
+	if !ok {
+		return false
+	}
+	if botUserID, err := b.botUserID(ctx, req.TeamID); err == nil {
+		text = strings.TrimPrefix(strings.TrimSpace(text), "<@"+botUserID+">")
+	}
+	text = decodeEntities(strings.TrimSpace(text))
+
+	var next Step
+	output, _ := b.execute(ctx, req, func(ctx context.Context) []slack.MsgOption {
+		if strings.EqualFold(text, "cancel") {
+			return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "cancelled"), false)}
+		}
+		var output []slack.MsgOption
+		output, next = step(ctx, text)
+		return output
+	})
+	b.sessions.resume(req, next)
+	if err := b.postAll(ctx, req, inThread(output, req.ThreadTS)); err != nil {
+		b.logger.Warn("failed to post session output", "channel", req.ChannelID, "err", err)
+	}
+	return true
+}
+
+// expireSession notifies the user that the session has expired.
+func (b *Bot) expireSession(ctx context.Context) func(Request) {
+	return func(req Request) {
+		b.logger.Debug("session expired", "channel", req.ChannelID, "user", req.UserID)
+		output, _ := b.execute(ctx, req, func(ctx context.Context) []slack.MsgOption {
+			return []slack.MsgOption{slack.MsgOptionText(Translate(ctx, "this conversation has expired"), false)}
+		})
+		if err := b.postAll(ctx, req, inThread(output, req.ThreadTS)); err != nil {
+			b.logger.Warn("failed to post session expiry", "channel", req.ChannelID, "err", err)
+		}
+	}
+}
+
+// inThread returns the responses, with the responses posted in the command's channel posted in the thread instead.
+func inThread(output []Response, threadTS string) []Response {
+	if threadTS == "" {
+		return output
+	}
+	threaded := make([]Response, len(output))
+	for i, response := range output {
+		if response.Target == TargetChannel {
+			response.Options = append(slices.Clone(response.Options), slack.MsgOptionTS(threadTS))
+		}
+		threaded[i] = response
+	}
+	return threaded
+}
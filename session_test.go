@@ -0,0 +1,115 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func onboard(ctx context.Context, _ ...string) []slack.MsgOption {
+	StartSession(ctx, func(ctx context.Context, team string) ([]slack.MsgOption, Step) {
+		return []slack.MsgOption{slack.MsgOptionText("what's your role?", false)}, func(ctx context.Context, role string) ([]slack.MsgOption, Step) {
+			return []slack.MsgOption{slack.MsgOptionText("welcome to "+team+", "+role, false)}, nil
+		}
+	})
+	return []slack.MsgOption{slack.MsgOptionText("what's your team?", false)}
+}
+
+func TestBot_Session(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("onboard", HandlerFunc(onboard)),
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		start      Request
+		replies    []Request
+		texts      []string
+		want       []string
+		wantThread string
+	}{
+		{
+			name:       "thread",
+			start:      Request{ChannelID: "C1", UserID: "U1", TS: "1.0"},
+			replies:    []Request{{ChannelID: "C1", UserID: "U1", TS: "2.0", ThreadTS: "1.0"}, {ChannelID: "C1", UserID: "U1", TS: "3.0", ThreadTS: "1.0"}},
+			texts:      []string{"ops", "<@W23456789> dev &amp; test"},
+			want:       []string{"what's your team?", "what's your role?", "welcome to ops, dev & test"},
+			wantThread: "1.0",
+		},
+		{
+			name:       "started in a thread",
+			start:      Request{ChannelID: "C1", UserID: "U1", TS: "4.0", ThreadTS: "1.0"},
+			replies:    []Request{{ChannelID: "C1", UserID: "U1", TS: "5.0", ThreadTS: "1.0"}},
+			texts:      []string{"cancel"},
+			want:       []string{"what's your team?", "cancelled"},
+			wantThread: "1.0",
+		},
+		{
+			name:    "direct message",
+			start:   Request{ChannelID: "D1", UserID: "U1", TS: "1.0"},
+			replies: []Request{{ChannelID: "D1", UserID: "U1", TS: "2.0"}, {ChannelID: "D1", UserID: "U1", TS: "3.0"}},
+			texts:   []string{"ops", "dev"},
+			want:    []string{"what's your team?", "what's your role?", "welcome to ops, dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, b.handle(ctx, tt.start, "<@W23456789> onboard"))
+			for i, req := range tt.replies {
+				// other users and other threads aren't passed to the session
+				assert.False(t, b.converse(ctx, Request{ChannelID: req.ChannelID, UserID: "U2", TS: req.TS, ThreadTS: req.ThreadTS}, "foo"))
+				if req.ThreadTS != "" {
+					assert.False(t, b.converse(ctx, Request{ChannelID: req.ChannelID, UserID: req.UserID, TS: req.TS}, "foo"))
+				}
+				assert.True(t, b.converse(ctx, req, tt.texts[i]))
+				// a message received twice is only passed to the session once, and isn't executed as a command
+				assert.True(t, b.converse(ctx, req, tt.texts[i]))
+			}
+			// the session has ended
+			last := tt.replies[len(tt.replies)-1]
+			last.TS = "10.0"
+			assert.False(t, b.converse(ctx, last, "foo"))
+
+			for _, want := range tt.want {
+				post := <-ts.post
+				assert.Equal(t, want, post.Get("text"))
+				assert.Equal(t, tt.wantThread, post.Get("thread_ts"))
+			}
+			assert.Empty(t, ts.post)
+		})
+	}
+}
+
+func TestBot_Session_Timeout(t *testing.T) {
+	ts := testServer{t: t, post: make(chan url.Values, 10)}
+	s := httptest.NewServer(&ts)
+	defer s.Close()
+
+	b := NewBot(slack.New("x0xb-foo", slack.OptionAPIURL(s.URL+"/")),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithCommand("onboard", HandlerFunc(onboard)),
+		WithSessionTimeout(100*time.Millisecond),
+	)
+	ctx := context.Background()
+	b.sessions.expire = b.expireSession(ctx)
+
+	require.NoError(t, b.handle(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "1.0"}, "<@W23456789> onboard"))
+	assert.Equal(t, "what's your team?", (<-ts.post).Get("text"))
+	post := <-ts.post
+	assert.Equal(t, "this conversation has expired", post.Get("text"))
+	assert.Equal(t, "1.0", post.Get("thread_ts"))
+	assert.False(t, b.converse(ctx, Request{ChannelID: "C1", UserID: "U1", TS: "2.0", ThreadTS: "1.0"}, "ops"))
+}
@@ -7,17 +7,52 @@ import (
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
 	"log/slog"
-	"sync/atomic"
+	"time"
 )
 
 // A SlackApp implements Slack's Events API, using Socket Mode. It connects to Slack,  listens for incoming events
-// and makes them available using the Event channel.
+// and makes them available using the Event channel. If TeamEvents is set, events are sent to TeamEvents instead,
+// along with the ID of the workspace where they occurred.
+//
+// Interactive events (block actions, shortcuts, view submissions) and slash commands need to be acknowledged
+// with a response payload. SlackApp passes these to InteractionHandler and SlashCommandHandler respectively and
+// acknowledges the event with the returned payload. If no handler is set, the event is acknowledged without a payload.
+// Handlers must be set before calling Run.
+//
+// SlackApp tracks the state of its connection to Slack (see Stats). When the connection fails or is closed, SlackApp
+// reconnects as per the Reconnect policy. Set OnStateChange to be notified when the state changes, and MaxDowntime and
+// OnDowntime to be alerted when the SlackApp can't reconnect in time.
+//
+// The embedded socketmode.Client uses the bot token of the client passed to NewSlackApp, and keeps using it when Clients
+// replaces a rotated token. Apps that are installed in multiple workspaces, or that have token rotation enabled, must not
+// call the Web API through the embedded client: they should set Clients, use ClientFor to get the client for a workspace,
+// and use TeamEvents to receive events.
 type SlackApp struct {
 	*socketmode.Client
 	Events chan slackevents.EventsAPIInnerEvent
+	// TeamEvents receives the events, instead of Events, if set. It must be set before calling Run.
+	TeamEvents          chan Event
+	InteractionHandler  func(slack.InteractionCallback) any
+	SlashCommandHandler func(slack.SlashCommand) any
+	Clients             ClientProvider
+	// Reconnect determines when, and how many times, Run reconnects to Slack after the connection failed.
+	Reconnect Backoff
+	// OnStateChange is called when the state of the connection changes.
+	OnStateChange func(ConnectionState)
+	// OnDowntime is called, with the current downtime, when the SlackApp is disconnected for longer than MaxDowntime.
+	// It's called once per outage.
+	OnDowntime  func(time.Duration)
+	MaxDowntime time.Duration
 	socketModeHandler
-	logger    *slog.Logger
-	connected atomic.Bool
+	logger     *slog.Logger
+	connection connection
+}
+
+// An Event is an Events API event received by the SlackApp. Since the App may be installed in multiple workspaces,
+// Event includes the ID of the workspace where the event occurred.
+type Event struct {
+	slackevents.EventsAPIInnerEvent
+	TeamID string
 }
 
 type socketModeHandler interface {
@@ -38,6 +73,9 @@ func newSlackAppWithSocketModeHandler(client *socketmode.Client, handler socketM
 		socketModeHandler: handler,
 		logger:            logger,
 	}
+	if client != nil {
+		app.Clients = staticClientProvider{client: &client.Client}
+	}
 	app.socketModeHandler.Handle(socketmode.EventTypeConnecting, app.onConnecting)
 	app.socketModeHandler.Handle(socketmode.EventTypeConnectionError, app.onConnectionError)
 	app.socketModeHandler.Handle(socketmode.EventTypeConnected, app.onConnected)
@@ -45,6 +83,8 @@ func newSlackAppWithSocketModeHandler(client *socketmode.Client, handler socketM
 	app.socketModeHandler.Handle(socketmode.EventTypeHello, app.onHello)
 	app.socketModeHandler.Handle(socketmode.EventTypeDisconnect, app.onDisconnected)
 	app.socketModeHandler.Handle(socketmode.EventTypeEventsAPI, app.onEvent)
+	app.socketModeHandler.Handle(socketmode.EventTypeInteractive, app.onInteractive)
+	app.socketModeHandler.Handle(socketmode.EventTypeSlashCommand, app.onSlashCommand)
 
 	return &app
 }
@@ -53,15 +93,21 @@ func newSlackAppWithSocketModeHandler(client *socketmode.Client, handler socketM
 func (h *SlackApp) Run(ctx context.Context) error {
 	h.logger.Info("starting SlackApp")
 	defer h.logger.Info("shutting down SlackApp")
-	return h.socketModeHandler.RunEventLoopContext(ctx)
+	return h.supervise(ctx)
+}
+
+// ClientFor returns the Slack client for the workspace, using Clients.
+func (h *SlackApp) ClientFor(ctx context.Context, teamID string) (*slack.Client, error) {
+	return h.Clients.Client(ctx, teamID)
 }
 
 // Connected returns true if the slackapp is connected to Slack.
 func (h *SlackApp) Connected() bool {
-	return h.connected.Load()
+	return h.Stats().State == StateConnected
 }
 
 func (h *SlackApp) onConnecting(_ *socketmode.Event, _ *socketmode.Client) {
+	h.setState(StateConnecting)
 	h.logger.Debug("connecting to Slack ...")
 }
 
@@ -70,11 +116,17 @@ func (h *SlackApp) onConnectionError(ev *socketmode.Event, _ *socketmode.Client)
 	if ev.Request != nil {
 		reason = ev.Request.Reason
 	}
+	var err error = errors.New(reason)
+	if connectionError, ok := ev.Data.(*slack.ConnectionErrorEvent); ok && connectionError.ErrorObj != nil {
+		err = connectionError.ErrorObj
+	}
+	h.setError(err)
 	h.logger.Error("failed to connect to Slack", "reason", reason)
+	h.restart(err)
 }
 
 func (h *SlackApp) onConnected(_ *socketmode.Event, _ *socketmode.Client) {
-	h.connected.Store(true)
+	h.setState(StateConnected)
 	h.logger.Info("connected to Slack")
 }
 
@@ -91,8 +143,8 @@ func (h *SlackApp) onHello(_ *socketmode.Event, _ *socketmode.Client) {
 }
 
 func (h *SlackApp) onDisconnected(_ *socketmode.Event, _ *socketmode.Client) {
-	h.connected.Store(false)
 	h.logger.Warn("disconnected from Slack")
+	h.restart(nil)
 }
 
 func (h *SlackApp) onEvent(ev *socketmode.Event, client *socketmode.Client) {
@@ -103,7 +155,47 @@ func (h *SlackApp) onEvent(ev *socketmode.Event, client *socketmode.Client) {
 	}
 	client.Ack(*ev.Request)
 	innerEvent := eventsAPIEvent.InnerEvent
-	h.logger.Debug("Event received", "type", innerEvent.Type)
+	h.logger.Debug("Event received", "type", innerEvent.Type, "team", eventsAPIEvent.TeamID)
 
+	if h.TeamEvents != nil {
+		h.TeamEvents <- Event{EventsAPIInnerEvent: innerEvent, TeamID: eventsAPIEvent.TeamID}
+		return
+	}
 	h.Events <- innerEvent
 }
+
+func (h *SlackApp) onInteractive(ev *socketmode.Event, client *socketmode.Client) {
+	callback, ok := ev.Data.(slack.InteractionCallback)
+	if !ok {
+		h.logger.Warn("received unexpected event type", "type", ev.Type)
+		return
+	}
+	h.logger.Debug("Interaction received", "type", callback.Type)
+	var payload any
+	if h.InteractionHandler != nil {
+		payload = h.InteractionHandler(callback)
+	}
+	ack(client, ev.Request, payload)
+}
+
+func (h *SlackApp) onSlashCommand(ev *socketmode.Event, client *socketmode.Client) {
+	cmd, ok := ev.Data.(slack.SlashCommand)
+	if !ok {
+		h.logger.Warn("received unexpected event type", "type", ev.Type)
+		return
+	}
+	h.logger.Debug("Slash command received", "command", cmd.Command)
+	var payload any
+	if h.SlashCommandHandler != nil {
+		payload = h.SlashCommandHandler(cmd)
+	}
+	ack(client, ev.Request, payload)
+}
+
+func ack(client *socketmode.Client, req *socketmode.Request, payload any) {
+	if payload == nil {
+		client.Ack(*req)
+		return
+	}
+	client.Ack(*req, payload)
+}
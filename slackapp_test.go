@@ -13,6 +13,7 @@ import (
 	"log/slog"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestSlackApp(t *testing.T) {
@@ -24,6 +25,7 @@ func TestSlackApp(t *testing.T) {
 	go func() { errChan <- app.Run(ctx) }()
 
 	// connect
+	assert.Eventually(t, func() bool { return app.Stats().State == StateConnecting }, time.Second, time.Millisecond)
 	assert.False(t, app.Connected())
 	app.onConnecting(nil, nil)
 	app.onConnected(nil, nil)
@@ -43,6 +45,17 @@ func TestSlackApp(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "hello world", mention.Text)
 
+	// with TeamEvents set, events are sent to TeamEvents, along with their workspace
+	app.TeamEvents = make(chan Event)
+	teamEvent := testutils.AppMentionEvent("hello world")
+	data := teamEvent.Data.(slackevents.EventsAPIEvent)
+	data.TeamID = "T1"
+	teamEvent.Data = data
+	go app.onEvent(teamEvent, socketmode.New(slackClient))
+	evtWithTeam := <-app.TeamEvents
+	assert.Equal(t, "T1", evtWithTeam.TeamID)
+	assert.Equal(t, string(slackevents.AppMention), evtWithTeam.Type)
+
 	// connection error / disconnect
 	app.onIncomingError(&socketmode.Event{Data: &slack.IncomingEventError{ErrorObj: errors.New("fail")}}, nil)
 	ev := socketmode.Event{
@@ -51,9 +64,42 @@ func TestSlackApp(t *testing.T) {
 	}
 	app.onConnectionError(&ev, nil)
 	app.onDisconnected(nil, nil)
-	assert.False(t, app.Connected())
+	assert.Eventually(t, func() bool { return !app.Connected() }, time.Second, time.Millisecond)
 
 	// shutdown
 	cancel()
 	assert.NoError(t, <-errChan)
 }
+
+func TestSlackApp_Interactions(t *testing.T) {
+	var h testutils.FakeHandler
+	app := newSlackAppWithSocketModeHandler(nil, &h, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	slackClient := slack.New("", slack.OptionHTTPClient(&http.Client{Transport: &testutils.StubbedRoundTripper{}}))
+	smClient := socketmode.New(slackClient)
+
+	// without handlers, events are acknowledged
+	h.SendInteraction(testutils.InteractionEvent(slack.InteractionCallback{Type: slack.InteractionTypeBlockActions}), smClient)
+	h.SendSlashCommand(testutils.SlashCommandEvent(slack.SlashCommand{Command: "/foo"}), smClient)
+
+	// with handlers, events are passed to the handler
+	interactions := make(chan slack.InteractionCallback, 1)
+	app.InteractionHandler = func(callback slack.InteractionCallback) any {
+		interactions <- callback
+		return nil
+	}
+	commands := make(chan slack.SlashCommand, 1)
+	app.SlashCommandHandler = func(cmd slack.SlashCommand) any {
+		commands <- cmd
+		return map[string]string{"text": "ok"}
+	}
+	h.SendInteraction(testutils.InteractionEvent(slack.InteractionCallback{Type: slack.InteractionTypeBlockActions}), smClient)
+	assert.Equal(t, slack.InteractionTypeBlockActions, (<-interactions).Type)
+	h.SendSlashCommand(testutils.SlashCommandEvent(slack.SlashCommand{Command: "/foo"}), smClient)
+	assert.Equal(t, "/foo", (<-commands).Command)
+
+	// invalid events are ignored
+	h.SendInteraction(&socketmode.Event{Type: socketmode.EventTypeInteractive}, smClient)
+	h.SendSlashCommand(&socketmode.Event{Type: socketmode.EventTypeSlashCommand}, smClient)
+	assert.Empty(t, interactions)
+	assert.Empty(t, commands)
+}
@@ -0,0 +1,143 @@
+package slackapp
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by a Store when a key does not exist.
+var ErrNotFound = errors.New("not found")
+
+// A Store is a key/value store that the Bot and Handlers can use to keep state.
+//
+// Handlers should use StoreFromContext, which returns a Store namespaced for the workspace and command being executed.
+type Store interface {
+	// Get returns the value for the key. It returns ErrNotFound if the key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the value for the key.
+	Set(ctx context.Context, key string, value []byte) error
+	// Delete removes the key. Deleting a key that does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+	// Keys returns all keys starting with prefix, in sorted order.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Namespace returns a Store that prefixes all keys with the namespace, so that different users of the same Store can't
+// see each other's keys. Namespaces can be nested: Namespace(Namespace(s, "a"), "b") is the same as Namespace(s, "a", "b").
+func Namespace(store Store, namespace ...string) Store {
+	if len(namespace) == 0 {
+		return store
+	}
+	prefix := strings.Join(namespace, "/") + "/"
+	if ns, ok := store.(namespacedStore); ok {
+		return namespacedStore{store: ns.store, prefix: ns.prefix + prefix}
+	}
+	return namespacedStore{store: store, prefix: prefix}
+}
+
+type namespacedStore struct {
+	store  Store
+	prefix string
+}
+
+func (n namespacedStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return n.store.Get(ctx, n.prefix+key)
+}
+
+func (n namespacedStore) Set(ctx context.Context, key string, value []byte) error {
+	return n.store.Set(ctx, n.prefix+key, value)
+}
+
+func (n namespacedStore) Delete(ctx context.Context, key string) error {
+	return n.store.Delete(ctx, n.prefix+key)
+}
+
+func (n namespacedStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := n.store.Keys(ctx, n.prefix+prefix)
+	for i := range keys {
+		keys[i] = strings.TrimPrefix(keys[i], n.prefix)
+	}
+	return keys, err
+}
+
+type storeKey struct{}
+
+func withStore(ctx context.Context, store Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// StoreFromContext returns the Store for the command being executed, namespaced for the workspace and the command.
+// It returns nil if the command wasn't issued through a Bot.
+func StoreFromContext(ctx context.Context) Store {
+	store, ok := ctx.Value(storeKey{}).(Store)
+	if !ok {
+		return nil
+	}
+	req, _ := RequestFromContext(ctx)
+	team := req.TeamID
+	if team == "" {
+		team = "default"
+	}
+	return Namespace(store, append([]string{"teams", team, "commands"}, CommandPath(ctx)...)...)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var _ Store = &MemoryStore{}
+
+// A MemoryStore is a Store that keeps all data in memory. The zero value is ready for use.
+type MemoryStore struct {
+	values map[string][]byte
+	lock   sync.RWMutex
+}
+
+// Get returns the value for the key.
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	value, ok := m.values[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+// Set sets the value for the key.
+func (m *MemoryStore) Set(_ context.Context, key string, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.values == nil {
+		m.values = make(map[string][]byte)
+	}
+	m.values[key] = slices.Clone(value)
+	return nil
+}
+
+// Delete removes the key.
+func (m *MemoryStore) Delete(_ context.Context, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// Keys returns all keys starting with prefix.
+func (m *MemoryStore) Keys(_ context.Context, prefix string) ([]string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return keysWithPrefix(m.values, prefix), nil
+}
+
+func keysWithPrefix(values map[string][]byte, prefix string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package slackapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+var _ Store = &FileStore{}
+
+// A FileStore is a Store that keeps its data in a JSON file. All data is kept in memory: every update rewrites the file.
+// This makes it suitable for small amounts of data, like schedules and user preferences.
+type FileStore struct {
+	path   string
+	values map[string][]byte
+	lock   sync.RWMutex
+}
+
+// NewFileStore returns a FileStore for the file at path. If the file exists, its contents are loaded.
+func NewFileStore(path string) (*FileStore, error) {
+	s := FileStore{path: path, values: make(map[string][]byte)}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &s, nil
+		}
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	if err = json.Unmarshal(body, &s.values); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Get returns the value for the key.
+func (f *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	value, ok := f.values[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+// Set sets the value for the key and saves the file.
+func (f *FileStore) Set(_ context.Context, key string, value []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.values[key] = slices.Clone(value)
+	return f.save()
+}
+
+// Delete removes the key and saves the file.
+func (f *FileStore) Delete(_ context.Context, key string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.values[key]; !ok {
+		return nil
+	}
+	delete(f.values, key)
+	return f.save()
+}
+
+// Keys returns all keys starting with prefix.
+func (f *FileStore) Keys(_ context.Context, prefix string) ([]string, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return keysWithPrefix(f.values, prefix), nil
+}
+
+// save writes the data to a temporary file and then renames it, so the file is never left partially written.
+func (f *FileStore) save() error {
+	body, err := json.Marshal(f.values)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err = tmp.Write(body); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package slackapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var _ Store = &SQLStore{}
+
+// A SQLStore is a Store that keeps its data in a database table, with a key column and a value column.
+// SQLStore uses database/sql: the application needs to import the driver for its database. SQLStore only issues
+// basic statements, but the syntax of bind parameters and the type of binary columns differ between databases: set
+// Placeholder and ValueType to match the database.
+type SQLStore struct {
+	db    *sql.DB
+	table string
+	// Placeholder returns the bind parameter for the n-th argument of a query (starting at 1).
+	// The default, QuestionMarkPlaceholder, uses "?". Use DollarPlaceholder for drivers that use numbered parameters.
+	Placeholder func(n int) string
+	// ValueType is the SQL type of the value column, used by CreateTable. The default is BLOB. Use e.g. BYTEA for
+	// databases without a BLOB type.
+	ValueType string
+}
+
+// QuestionMarkPlaceholder uses "?" as bind parameter.
+func QuestionMarkPlaceholder(_ int) string { return "?" }
+
+// DollarPlaceholder uses "$1", "$2", etc. as bind parameters.
+func DollarPlaceholder(n int) string { return "$" + strconv.Itoa(n) }
+
+// NewSQLStore returns a SQLStore that uses the table in the database. Call CreateTable to create the table if it does not exist yet.
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	return &SQLStore{db: db, table: table, Placeholder: QuestionMarkPlaceholder, ValueType: "BLOB"}
+}
+
+// CreateTable creates the SQLStore's table if it does not exist.
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+" (k VARCHAR(255) PRIMARY KEY, v "+s.ValueType+")")
+	return err
+}
+
+// Get returns the value for the key.
+func (s *SQLStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx, "SELECT v FROM "+s.table+" WHERE k = "+s.Placeholder(1), key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// Set sets the value for the key. As upsert syntax differs between databases, Set deletes the key and inserts the new value
+// in one transaction.
+func (s *SQLStore) Set(ctx context.Context, key string, value []byte) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE k = "+s.Placeholder(1), key)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+s.table+" (k, v) VALUES ("+s.Placeholder(1)+", "+s.Placeholder(2)+")", key, value)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes the key.
+func (s *SQLStore) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE k = "+s.Placeholder(1), key)
+	return err
+}
+
+// likeEscaper escapes the wildcards in a LIKE pattern. It uses '!' as escape character, as the backslash is itself an
+// escape character in string literals in some databases.
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// Keys returns all keys starting with prefix.
+func (s *SQLStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT k FROM "+s.table+" WHERE k LIKE "+s.Placeholder(1)+" ESCAPE '!' ORDER BY k", likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,115 @@
+package slackapp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSQLStore(t *testing.T) {
+	d := fakeDriver{values: make(map[string][]byte)}
+	s := NewSQLStore(sql.OpenDB(&d), "store")
+	s.ValueType = "BYTEA"
+	require.NoError(t, s.CreateTable(context.Background()))
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS store (k VARCHAR(255) PRIMARY KEY, v BYTEA)", d.created)
+	testStore(t, s)
+
+	// LIKE wildcards and the escape character in the prefix match literally
+	ctx := context.Background()
+	require.NoError(t, s.Set(ctx, "100%!", []byte("1")))
+	require.NoError(t, s.Set(ctx, "1000", []byte("2")))
+	keys, err := s.Keys(ctx, "100%!")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100%!"}, keys)
+
+	assert.Equal(t, "$2", DollarPlaceholder(2))
+}
+
+// fakeDriver is a database/sql driver that supports the statements issued by SQLStore. It's used as a
+// driver.Connector, so it doesn't need to be registered.
+type fakeDriver struct {
+	values  map[string][]byte
+	created string
+}
+
+func (d *fakeDriver) Open(_ string) (driver.Conn, error)             { return &fakeConn{driver: d}, nil }
+func (d *fakeDriver) Connect(_ context.Context) (driver.Conn, error) { return d.Open("") }
+func (d *fakeDriver) Driver() driver.Driver                          { return d }
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(q string) (driver.Stmt, error) { return &fakeStmt{conn: c, query: q}, nil }
+func (c *fakeConn) Close() error                          { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)             { return c, nil }
+func (c *fakeConn) Commit() error                         { return nil }
+func (c *fakeConn) Rollback() error                       { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+		s.conn.driver.created = s.query
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.conn.driver.values, args[0].(string))
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.conn.driver.values[args[0].(string)] = args[1].([]byte)
+	default:
+		return nil, fmt.Errorf("unsupported statement: %s", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	var rows fakeRows
+	switch {
+	case strings.HasPrefix(s.query, "SELECT v"):
+		rows.column = "v"
+		if value, ok := s.conn.driver.values[args[0].(string)]; ok {
+			rows.values = append(rows.values, value)
+		}
+	case strings.HasPrefix(s.query, "SELECT k"):
+		rows.column = "k"
+		if !strings.Contains(s.query, "ESCAPE '!'") {
+			return nil, fmt.Errorf("unsupported query: %s", s.query)
+		}
+		pattern := args[0].(string)
+		prefix := strings.NewReplacer(`!!`, `!`, `!%`, `%`, `!_`, `_`).Replace(strings.TrimSuffix(pattern, "%"))
+		for _, key := range keysWithPrefix(s.conn.driver.values, prefix) {
+			rows.values = append(rows.values, key)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported query: %s", s.query)
+	}
+	return &rows, nil
+}
+
+type fakeRows struct {
+	column string
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{r.column} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
@@ -0,0 +1,89 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "foo")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Set(ctx, "foo", []byte("1")))
+	require.NoError(t, s.Set(ctx, "foo", []byte("2")))
+	require.NoError(t, s.Set(ctx, "foo/bar", []byte("3")))
+	require.NoError(t, s.Set(ctx, "foo_bar", []byte("4")))
+	require.NoError(t, s.Set(ctx, "snafu", []byte("5")))
+
+	value, err := s.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(value))
+
+	keys, err := s.Keys(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "foo/bar", "foo_bar"}, keys)
+	keys, err = s.Keys(ctx, "foo/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar"}, keys)
+
+	require.NoError(t, s.Delete(ctx, "foo"))
+	require.NoError(t, s.Delete(ctx, "foo"))
+	_, err = s.Get(ctx, "foo")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, &MemoryStore{})
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, err := NewFileStore(path)
+	require.NoError(t, err)
+	testStore(t, s)
+
+	// data is reloaded from the file
+	s, err = NewFileStore(path)
+	require.NoError(t, err)
+	value, err := s.Get(context.Background(), "snafu")
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(value))
+
+	// invalid file
+	_, err = NewFileStore(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestNamespace(t *testing.T) {
+	var s MemoryStore
+	testStore(t, Namespace(&s, "a"))
+	testStore(t, Namespace(Namespace(&s, "b"), "c"))
+	assert.Equal(t, Store(&s), Namespace(&s))
+
+	ctx := context.Background()
+	keys, err := s.Keys(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/foo/bar", "a/foo_bar", "a/snafu", "b/c/foo/bar", "b/c/foo_bar", "b/c/snafu"}, keys)
+}
+
+func TestStoreFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, StoreFromContext(ctx))
+
+	var s MemoryStore
+	ctx = withStore(withCommandPath(withCommandPath(ctx, "foo"), "bar"), &s)
+	require.NoError(t, StoreFromContext(ctx).Set(ctx, "key", []byte("value")))
+	_, err := s.Get(ctx, "teams/default/commands/foo/bar/key")
+	assert.NoError(t, err)
+
+	ctx = withRequest(ctx, Request{TeamID: "T1"})
+	require.NoError(t, StoreFromContext(ctx).Set(ctx, "key", []byte("value")))
+	_, err = s.Get(ctx, "teams/T1/commands/foo/bar/key")
+	assert.NoError(t, err)
+}
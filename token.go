@@ -0,0 +1,99 @@
+package slackapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/slack-go/slack"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A TokenProvider returns the current bot token for a workspace.
+type TokenProvider interface {
+	Token(ctx context.Context, teamID string) (string, error)
+}
+
+// TokenProviderFunc is an adapter that allows a function to be used as a TokenProvider
+type TokenProviderFunc func(ctx context.Context, teamID string) (string, error)
+
+// Token calls f(ctx, teamID)
+func (f TokenProviderFunc) Token(ctx context.Context, teamID string) (string, error) {
+	return f(ctx, teamID)
+}
+
+// defaultRefreshMargin is how long before a token expires that a TokenRefresher refreshes it.
+const defaultRefreshMargin = 5 * time.Minute
+
+var _ TokenProvider = &TokenRefresher{}
+
+// A TokenRefresher is a TokenProvider for apps with token rotation enabled. It returns the bot token from the workspace's
+// Installation. If the token expires within RefreshMargin, it first uses the Installation's refresh token to get a new token
+// (using oauth.v2.access) and saves the updated Installation.
+//
+// Installations without a refresh token (i.e. installed before token rotation was enabled) are returned as-is.
+type TokenRefresher struct {
+	ClientID     string
+	ClientSecret string
+	Tokens       TokenStore
+	// RefreshMargin determines how long before expiry a token is refreshed. The default is 5 minutes.
+	RefreshMargin time.Duration
+	// HTTPClient is used to refresh tokens. The default is http.DefaultClient.
+	HTTPClient *http.Client
+	// refresh tokens can only be used once: serialize all refreshes
+	lock sync.Mutex
+}
+
+// Token returns the workspace's bot token, refreshing it if it's about to expire.
+func (r *TokenRefresher) Token(ctx context.Context, teamID string) (string, error) {
+	installation, err := r.Tokens.Get(ctx, teamID)
+	if err != nil {
+		return "", fmt.Errorf("team %s: %w", teamID, err)
+	}
+	if !r.mustRefresh(installation) {
+		return installation.BotToken, nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	// another caller may have refreshed the token while we were waiting for the lock
+	if installation, err = r.Tokens.Get(ctx, teamID); err != nil {
+		return "", fmt.Errorf("team %s: %w", teamID, err)
+	}
+	if !r.mustRefresh(installation) {
+		return installation.BotToken, nil
+	}
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := slack.RefreshOAuthV2TokenContext(ctx, httpClient, r.ClientID, r.ClientSecret, installation.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("team %s: refresh token: %w", teamID, err)
+	}
+	installation.BotToken = resp.AccessToken
+	installation.RefreshToken = resp.RefreshToken
+	installation.ExpiresAt = expiresAt(resp.ExpiresIn)
+	if err = r.Tokens.Save(ctx, installation); err != nil {
+		return "", fmt.Errorf("team %s: save: %w", teamID, err)
+	}
+	return installation.BotToken, nil
+}
+
+func (r *TokenRefresher) mustRefresh(installation Installation) bool {
+	if installation.RefreshToken == "" || installation.ExpiresAt.IsZero() {
+		return false
+	}
+	margin := r.RefreshMargin
+	if margin == 0 {
+		margin = defaultRefreshMargin
+	}
+	return time.Until(installation.ExpiresAt) < margin
+}
+
+func expiresAt(expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(expiresIn) * time.Second)
+}
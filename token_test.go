@@ -0,0 +1,89 @@
+package slackapp
+
+import (
+	"context"
+	"github.com/clambin/slackapp/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenRefresher(t *testing.T) {
+	var refreshes atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "xoxe-1" {
+			_, _ = w.Write([]byte(`{"ok":false,"error":"invalid_refresh_token"}`))
+			return
+		}
+		refreshes.Add(1)
+		_, _ = w.Write([]byte(`{"ok":true,"access_token":"xoxe.xoxb-2","refresh_token":"xoxe-2","expires_in":43200}`))
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	tokens := NewTokenStore(&MemoryStore{})
+	r := TokenRefresher{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Tokens:       tokens,
+		HTTPClient:   &http.Client{Transport: redirectTransport{target: s.URL}},
+	}
+
+	// unknown team
+	_, err := r.Token(ctx, "T1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// no token rotation
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxb-1"}))
+	token, err := r.Token(ctx, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, "xoxb-1", token)
+
+	// token not about to expire
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxe.xoxb-1", RefreshToken: "xoxe-1", ExpiresAt: time.Now().Add(time.Hour)}))
+	token, err = r.Token(ctx, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, "xoxe.xoxb-1", token)
+	assert.Zero(t, refreshes.Load())
+
+	// token about to expire
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxe.xoxb-1", RefreshToken: "xoxe-1", ExpiresAt: time.Now().Add(time.Minute)}))
+	token, err = r.Token(ctx, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, "xoxe.xoxb-2", token)
+	assert.Equal(t, int32(1), refreshes.Load())
+	installation, err := tokens.Get(ctx, "T1")
+	require.NoError(t, err)
+	assert.Equal(t, "xoxe-2", installation.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(12*time.Hour), installation.ExpiresAt, time.Minute)
+
+	// refresh fails
+	require.NoError(t, tokens.Save(ctx, Installation{TeamID: "T1", BotToken: "xoxe.xoxb-1", RefreshToken: "xoxe-3", ExpiresAt: time.Now()}))
+	_, err = r.Token(ctx, "T1")
+	assert.Error(t, err)
+}
+
+func TestNewTokenClientProvider(t *testing.T) {
+	token := "xoxb-1"
+	p := NewTokenClientProvider(TokenProviderFunc(func(_ context.Context, _ string) (string, error) { return token, nil }))
+	var h testutils.FakeHandler
+	app := newSlackAppWithSocketModeHandler(nil, &h, nil)
+	app.Clients = p
+
+	c1, err := app.ClientFor(context.Background(), "T1")
+	require.NoError(t, err)
+	c2, err := app.ClientFor(context.Background(), "T1")
+	require.NoError(t, err)
+	assert.Same(t, c1, c2)
+
+	// when the token changes, the client is replaced
+	token = "xoxb-2"
+	c2, err = app.ClientFor(context.Background(), "T1")
+	require.NoError(t, err)
+	assert.NotSame(t, c1, c2)
+}